@@ -0,0 +1,78 @@
+package hlog
+
+import (
+	"os"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// stripColor 是否需要为非终端输出去除颜色: 级别编码带颜色且未设置ForceColor
+func stripColor(config *EncoderConfig) bool {
+	if config == nil || config.ForceColor {
+		return false
+	}
+	return config.EncodeLevel == "capitalColor" || config.EncodeLevel == "color"
+}
+
+// plainLevelEncoder 返回与带颜色的级别编码对应的不带颜色的级别编码
+func plainLevelEncoder(encodeLevel string) zapcore.LevelEncoder {
+	if encodeLevel == "capitalColor" {
+		return zapcore.CapitalLevelEncoder
+	}
+	return zapcore.LowercaseLevelEncoder
+}
+
+// isTerminal f是否为终端
+func isTerminal(f *os.File) bool {
+	stat, err := f.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
+
+// output 一个日志输出
+type output struct {
+	ws    zapcore.WriteSyncer
+	color bool // 是否保留级别颜色, 只有终端的标准输出和标准错误保留
+}
+
+// stdOutput 返回标准输出或标准错误, f为终端时保留颜色
+func stdOutput(f *os.File) output {
+	return output{ws: zapcore.Lock(f), color: isTerminal(f)}
+}
+
+// encoderPair 带颜色和不带颜色的编码器, plain为nil时所有输出都使用color(级别编码不带颜色或设置了ForceColor)
+type encoderPair struct {
+	color zapcore.Encoder
+	plain zapcore.Encoder
+}
+
+// pick 返回写入输出使用的编码器
+func (e encoderPair) pick(color bool) zapcore.Encoder {
+	if color || e.plain == nil {
+		return e.color
+	}
+	return e.plain
+}
+
+// newCore 为outputs创建core: 按是否保留颜色分组, 每组使用对应的编码器并按redactKeys脱敏, 两组都有时返回Tee
+func (e encoderPair) newCore(outputs []output, enab zapcore.LevelEnabler, redactKeys []string) zapcore.Core {
+	var color, plain []zapcore.WriteSyncer
+	for _, o := range outputs {
+		if o.color || e.plain == nil {
+			color = append(color, o.ws)
+		} else {
+			plain = append(plain, o.ws)
+		}
+	}
+
+	var cores []zapcore.Core
+	if len(color) > 0 || len(plain) == 0 {
+		cores = append(cores, newRedactKeyCore(zapcore.NewCore(e.color.Clone(), zapcore.NewMultiWriteSyncer(color...), enab), redactKeys))
+	}
+	if len(plain) > 0 {
+		cores = append(cores, newRedactKeyCore(zapcore.NewCore(e.plain.Clone(), zapcore.NewMultiWriteSyncer(plain...), enab), redactKeys))
+	}
+	if len(cores) == 1 {
+		return cores[0]
+	}
+	return zapcore.NewTee(cores...)
+}
@@ -0,0 +1,93 @@
+package hlog
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// trace字段的键名
+const (
+	TraceIDKey = "trace_id"
+	SpanIDKey  = "span_id"
+)
+
+// contextField 需要从context中提取的值
+type contextField struct {
+	key       any
+	fieldName string
+}
+
+var (
+	contextFieldsMu sync.RWMutex
+	contextFields   []contextField
+)
+
+// RegisterContextField 注册需要从context中提取的值, WithContext会将ctx.Value(key)以fieldName输出
+//
+// 同一个key重复注册时更新字段名; 通常在程序初始化时调用
+func RegisterContextField(key any, fieldName string) {
+	contextFieldsMu.Lock()
+	defer contextFieldsMu.Unlock()
+
+	for i := range contextFields {
+		if contextFields[i].key == key {
+			contextFields[i].fieldName = fieldName
+			return
+		}
+	}
+	contextFields = append(contextFields, contextField{key: key, fieldName: fieldName})
+}
+
+// ContextFields 提取ctx中已注册的值作为zap字段, 未设置的值会被跳过
+func ContextFields(ctx context.Context) []zap.Field {
+	if ctx == nil {
+		return nil
+	}
+
+	contextFieldsMu.RLock()
+	defer contextFieldsMu.RUnlock()
+
+	var fields []zap.Field
+	for _, f := range contextFields {
+		if v := ctx.Value(f.key); v != nil {
+			fields = append(fields, zap.Any(f.fieldName, v))
+		}
+	}
+	return fields
+}
+
+// WithContext 返回附带ctx中已注册字段的子logger, 没有可提取的字段时返回原logger
+func (zl *zapLogger) WithContext(ctx context.Context) HLogger {
+	fields := ContextFields(ctx)
+	if len(fields) == 0 {
+		return zl
+	}
+	return zl.With(fields...)
+}
+
+// TraceFields 提取ctx中有效的OpenTelemetry SpanContext作为trace_id和span_id字段, 没有span时返回nil
+func TraceFields(ctx context.Context) []zap.Field {
+	if ctx == nil {
+		return nil
+	}
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+	return []zap.Field{
+		zap.String(TraceIDKey, sc.TraceID().String()),
+		zap.String(SpanIDKey, sc.SpanID().String()),
+	}
+}
+
+// WithTraceContext 返回附带ctx中span的trace_id和span_id的子logger, 没有span时返回原logger, 不产生额外开销
+func (zl *zapLogger) WithTraceContext(ctx context.Context) HLogger {
+	fields := TraceFields(ctx)
+	if len(fields) == 0 {
+		return zl
+	}
+	return zl.With(fields...)
+}
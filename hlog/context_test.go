@@ -0,0 +1,91 @@
+package hlog
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"gorm.io/gorm/logger"
+)
+
+type traceIDKey struct{}
+
+// TestWithContext 测试从context提取已注册字段
+func TestWithContext(t *testing.T) {
+	RegisterContextField(traceIDKey{}, "trace_id")
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	hlogger := &zapLogger{logger: zap.New(core)}
+
+	ctx := context.WithValue(context.Background(), traceIDKey{}, "trace-1")
+	hlogger.WithContext(ctx).Info("with trace")
+	hlogger.WithContext(context.Background()).Info("without trace")
+
+	entries := logs.All()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if got := entries[0].ContextMap()["trace_id"]; got != "trace-1" {
+		t.Errorf("expected trace_id trace-1, got %v", got)
+	}
+	if _, ok := entries[1].ContextMap()["trace_id"]; ok {
+		t.Errorf("unexpected trace_id without context value: %v", entries[1].ContextMap())
+	}
+}
+
+// TestGormTraceContext 测试GORM日志携带context中的字段
+func TestGormTraceContext(t *testing.T) {
+	RegisterContextField(traceIDKey{}, "trace_id")
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	gormLogger := NewGormLogger(&zapLogger{logger: zap.New(core)}, &logger.Config{
+		LogLevel: logger.Info,
+	})
+
+	ctx := context.WithValue(context.Background(), traceIDKey{}, "trace-2")
+	gormLogger.Trace(ctx, time.Now(), func() (string, int64) {
+		return "SELECT 1", 1
+	}, nil)
+	gormLogger.Info(ctx, "info %s", "message")
+
+	for _, entry := range logs.All() {
+		if got := entry.ContextMap()["trace_id"]; got != "trace-2" {
+			t.Errorf("%q: expected trace_id trace-2, got %v", entry.Message, got)
+		}
+	}
+	if logs.Len() != 2 {
+		t.Errorf("expected 2 entries, got %d", logs.Len())
+	}
+}
+
+// TestWithTraceContext 测试从context中的span提取trace_id和span_id
+func TestWithTraceContext(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	hlogger := &zapLogger{logger: zap.New(core)}
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10},
+		SpanID:     trace.SpanID{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+	hlogger.WithTraceContext(ctx).Info("traced")
+
+	// 没有span时返回原logger
+	if got := hlogger.WithTraceContext(context.Background()); got != HLogger(hlogger) {
+		t.Error("expected the original logger when no span is present")
+	}
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields[TraceIDKey] != "0102030405060708090a0b0c0d0e0f10" || fields[SpanIDKey] != "0102030405060708" {
+		t.Errorf("unexpected trace fields: %v", fields)
+	}
+}
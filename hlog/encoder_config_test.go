@@ -1,7 +1,10 @@
 package hlog
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -194,3 +197,36 @@ func TestEncoderConfigWithCustomKeysAndHide(t *testing.T) {
 		t.Logf("Log file created successfully: %s", logFile)
 	}
 }
+
+// TestColorOutput 测试带颜色的级别编码在文件中不带颜色, ForceColor时保留
+func TestColorOutput(t *testing.T) {
+	dir := t.TempDir()
+	for _, encoder := range []string{"console", "json"} {
+		for _, force := range []bool{false, true} {
+			logFile := filepath.Join(dir, fmt.Sprintf("%s_%v.log", encoder, force))
+			logger, err := NewZapLogger(LoggerConfig{
+				Level:         "info",
+				OutputPath:    []string{logFile},
+				Encoder:       encoder,
+				EncoderConfig: &EncoderConfig{EncodeLevel: "capitalColor", ForceColor: force},
+			})
+			if err != nil {
+				t.Fatalf("Failed to create logger: %v", err)
+			}
+			// 消息中的转义序列属于用户内容, 原样保留
+			logger.Error("colored \x1b[1mlevel\x1b[0m")
+			data, _ := SyncAndRead(logger, logFile)
+			logger.Close()
+
+			// json编码器将ESC转义为\u001b
+			content := strings.ReplaceAll(string(data), `\u001b`, "\x1b")
+			if !strings.Contains(content, "ERROR") || !strings.Contains(content, "\x1b[1mlevel\x1b[0m") {
+				t.Errorf("%s force=%v: expected level and message in output: %q", encoder, force, data)
+			}
+			colored := strings.Contains(content, "\x1b[31mERROR\x1b[0m")
+			if colored != force {
+				t.Errorf("%s force=%v: unexpected level color presence %v: %q", encoder, force, colored, data)
+			}
+		}
+	}
+}
@@ -0,0 +1,350 @@
+package hlog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// jsonMarshalerType json.Marshaler接口类型, 自定义序列化的值不做处理
+var jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// isNonFinite f是否为NaN或Inf
+func isNonFinite(f float64) bool {
+	return math.IsNaN(f) || math.IsInf(f, 0)
+}
+
+// nonFiniteFloat NaN和Inf的替换方式
+type nonFiniteFloat struct {
+	null     bool   // 输出为null
+	sentinel string // null为false时输出的字符串
+}
+
+// newNonFiniteFloat 按EncoderConfig.NonFiniteFloat创建替换方式, 未设置时返回nil
+func newNonFiniteFloat(config *EncoderConfig) *nonFiniteFloat {
+	if config == nil || config.NonFiniteFloat == "" {
+		return nil
+	}
+	return &nonFiniteFloat{null: config.NonFiniteFloat == "null", sentinel: config.NonFiniteFloat}
+}
+
+// value 反射值中的替换内容
+func (n *nonFiniteFloat) value() interface{} {
+	if n.null {
+		return nil
+	}
+	return n.sentinel
+}
+
+// field 替换后的字段
+func (n *nonFiniteFloat) field(key string) zapcore.Field {
+	if n.null {
+		return zap.Reflect(key, nil)
+	}
+	return zap.String(key, n.sentinel)
+}
+
+// add 向对象写入替换内容
+func (n *nonFiniteFloat) add(enc zapcore.ObjectEncoder, key string) {
+	if n.null {
+		_ = enc.AddReflected(key, nil)
+		return
+	}
+	enc.AddString(key, n.sentinel)
+}
+
+// append 向数组写入替换内容
+func (n *nonFiniteFloat) append(enc zapcore.ArrayEncoder) {
+	if n.null {
+		_ = enc.AppendReflected(nil)
+		return
+	}
+	enc.AppendString(n.sentinel)
+}
+
+// sanitizeField 替换浮点字段中的NaN和Inf, 对象和数组字段在编码时逐个处理; 字段被替换时返回true
+func (n *nonFiniteFloat) sanitizeField(f zapcore.Field) (zapcore.Field, bool) {
+	switch f.Type {
+	case zapcore.Float64Type:
+		if isNonFinite(math.Float64frombits(uint64(f.Integer))) {
+			return n.field(f.Key), true
+		}
+	case zapcore.Float32Type:
+		if isNonFinite(float64(math.Float32frombits(uint32(f.Integer)))) {
+			return n.field(f.Key), true
+		}
+	case zapcore.ObjectMarshalerType:
+		if obj, ok := f.Interface.(zapcore.ObjectMarshaler); ok {
+			f.Interface = floatObject{ObjectMarshaler: obj, n: n}
+			return f, true
+		}
+	case zapcore.ArrayMarshalerType:
+		if arr, ok := f.Interface.(zapcore.ArrayMarshaler); ok {
+			f.Interface = floatArray{ArrayMarshaler: arr, n: n}
+			return f, true
+		}
+	}
+	return f, false
+}
+
+// sanitizeFields 返回替换后的字段, 没有需要处理的字段时返回原切片
+func (n *nonFiniteFloat) sanitizeFields(fields []zapcore.Field) []zapcore.Field {
+	var out []zapcore.Field
+	for i, f := range fields {
+		sf, changed := n.sanitizeField(f)
+		if out == nil {
+			if !changed {
+				continue
+			}
+			out = make([]zapcore.Field, len(fields))
+			copy(out, fields[:i])
+		}
+		out[i] = sf
+	}
+	if out == nil {
+		return fields
+	}
+	return out
+}
+
+// sanitizeValue 将反射值转换为可json序列化的值, 其中的NaN和Inf替换为n.value()
+//
+// 结构体转换为map, 键名取json标签; 实现json.Marshaler的值原样返回
+func (n *nonFiniteFloat) sanitizeValue(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	if v.Type().Implements(jsonMarshalerType) {
+		return v.Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		if isNonFinite(v.Float()) {
+			return n.value()
+		}
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return n.sanitizeValue(v.Elem())
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			break
+		}
+		out := make([]interface{}, v.Len())
+		for i := range out {
+			out[i] = n.sanitizeValue(v.Index(i))
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		out := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out[fmt.Sprint(iter.Key().Interface())] = n.sanitizeValue(iter.Value())
+		}
+		return out
+	case reflect.Struct:
+		if reflect.PointerTo(v.Type()).Implements(jsonMarshalerType) {
+			break
+		}
+		t := v.Type()
+		out := make(map[string]interface{}, t.NumField())
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if !sf.IsExported() {
+				continue
+			}
+			name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = sf.Name
+			}
+			out[name] = n.sanitizeValue(v.Field(i))
+		}
+		return out
+	}
+	return v.Interface()
+}
+
+// floatReflectedEncoder 序列化失败于NaN或Inf时, 替换后重新序列化的zapcore.ReflectedEncoder
+type floatReflectedEncoder struct {
+	enc *json.Encoder
+	n   *nonFiniteFloat
+}
+
+// newFloatReflectedEncoder 返回EncoderConfig.NewReflectedEncoder, 与zap默认实现一样不转义HTML
+func (n *nonFiniteFloat) newFloatReflectedEncoder(w io.Writer) zapcore.ReflectedEncoder {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return floatReflectedEncoder{enc: enc, n: n}
+}
+
+// Encode 实现zapcore.ReflectedEncoder接口
+func (e floatReflectedEncoder) Encode(obj interface{}) error {
+	err := e.enc.Encode(obj)
+	var unsupported *json.UnsupportedValueError
+	if errors.As(err, &unsupported) {
+		return e.enc.Encode(e.n.sanitizeValue(reflect.ValueOf(obj)))
+	}
+	return err
+}
+
+// floatEncoder 将浮点字段中的NaN和Inf替换为null或指定字符串的编码器, 保证json输出中的数值字段可被解析
+type floatEncoder struct {
+	zapcore.Encoder
+	n *nonFiniteFloat
+}
+
+// newFloatEncoder 包装编码器, 未设置NonFiniteFloat时返回原编码器
+func newFloatEncoder(enc zapcore.Encoder, config *EncoderConfig) zapcore.Encoder {
+	n := newNonFiniteFloat(config)
+	if n == nil {
+		return enc
+	}
+	return &floatEncoder{Encoder: enc, n: n}
+}
+
+// Clone 实现zapcore.Encoder接口
+func (e *floatEncoder) Clone() zapcore.Encoder {
+	return &floatEncoder{Encoder: e.Encoder.Clone(), n: e.n}
+}
+
+// EncodeEntry 实现zapcore.Encoder接口
+func (e *floatEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	return e.Encoder.EncodeEntry(ent, e.n.sanitizeFields(fields))
+}
+
+// AddFloat64 实现zapcore.ObjectEncoder接口, With附加的字段经由此处编码
+func (e *floatEncoder) AddFloat64(key string, v float64) {
+	if isNonFinite(v) {
+		e.n.add(e.Encoder, key)
+		return
+	}
+	e.Encoder.AddFloat64(key, v)
+}
+
+// AddFloat32 实现zapcore.ObjectEncoder接口
+func (e *floatEncoder) AddFloat32(key string, v float32) {
+	if isNonFinite(float64(v)) {
+		e.n.add(e.Encoder, key)
+		return
+	}
+	e.Encoder.AddFloat32(key, v)
+}
+
+// AddObject 实现zapcore.ObjectEncoder接口
+func (e *floatEncoder) AddObject(key string, v zapcore.ObjectMarshaler) error {
+	return e.Encoder.AddObject(key, floatObject{ObjectMarshaler: v, n: e.n})
+}
+
+// AddArray 实现zapcore.ObjectEncoder接口
+func (e *floatEncoder) AddArray(key string, v zapcore.ArrayMarshaler) error {
+	return e.Encoder.AddArray(key, floatArray{ArrayMarshaler: v, n: e.n})
+}
+
+// floatObject 编码时替换NaN和Inf的对象
+type floatObject struct {
+	zapcore.ObjectMarshaler
+	n *nonFiniteFloat
+}
+
+// MarshalLogObject 实现zapcore.ObjectMarshaler接口
+func (o floatObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	return o.ObjectMarshaler.MarshalLogObject(&floatObjectEncoder{ObjectEncoder: enc, n: o.n})
+}
+
+// floatArray 编码时替换NaN和Inf的数组, 如zap.Float64s
+type floatArray struct {
+	zapcore.ArrayMarshaler
+	n *nonFiniteFloat
+}
+
+// MarshalLogArray 实现zapcore.ArrayMarshaler接口
+func (a floatArray) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	return a.ArrayMarshaler.MarshalLogArray(&floatArrayEncoder{ArrayEncoder: enc, n: a.n})
+}
+
+// floatObjectEncoder 替换NaN和Inf的ObjectEncoder
+type floatObjectEncoder struct {
+	zapcore.ObjectEncoder
+	n *nonFiniteFloat
+}
+
+// AddFloat64 实现zapcore.ObjectEncoder接口
+func (e *floatObjectEncoder) AddFloat64(key string, v float64) {
+	if isNonFinite(v) {
+		e.n.add(e.ObjectEncoder, key)
+		return
+	}
+	e.ObjectEncoder.AddFloat64(key, v)
+}
+
+// AddFloat32 实现zapcore.ObjectEncoder接口
+func (e *floatObjectEncoder) AddFloat32(key string, v float32) {
+	if isNonFinite(float64(v)) {
+		e.n.add(e.ObjectEncoder, key)
+		return
+	}
+	e.ObjectEncoder.AddFloat32(key, v)
+}
+
+// AddObject 实现zapcore.ObjectEncoder接口
+func (e *floatObjectEncoder) AddObject(key string, v zapcore.ObjectMarshaler) error {
+	return e.ObjectEncoder.AddObject(key, floatObject{ObjectMarshaler: v, n: e.n})
+}
+
+// AddArray 实现zapcore.ObjectEncoder接口
+func (e *floatObjectEncoder) AddArray(key string, v zapcore.ArrayMarshaler) error {
+	return e.ObjectEncoder.AddArray(key, floatArray{ArrayMarshaler: v, n: e.n})
+}
+
+// floatArrayEncoder 替换NaN和Inf的ArrayEncoder
+type floatArrayEncoder struct {
+	zapcore.ArrayEncoder
+	n *nonFiniteFloat
+}
+
+// AppendFloat64 实现zapcore.ArrayEncoder接口
+func (e *floatArrayEncoder) AppendFloat64(v float64) {
+	if isNonFinite(v) {
+		e.n.append(e.ArrayEncoder)
+		return
+	}
+	e.ArrayEncoder.AppendFloat64(v)
+}
+
+// AppendFloat32 实现zapcore.ArrayEncoder接口
+func (e *floatArrayEncoder) AppendFloat32(v float32) {
+	if isNonFinite(float64(v)) {
+		e.n.append(e.ArrayEncoder)
+		return
+	}
+	e.ArrayEncoder.AppendFloat32(v)
+}
+
+// AppendObject 实现zapcore.ArrayEncoder接口
+func (e *floatArrayEncoder) AppendObject(v zapcore.ObjectMarshaler) error {
+	return e.ArrayEncoder.AppendObject(floatObject{ObjectMarshaler: v, n: e.n})
+}
+
+// AppendArray 实现zapcore.ArrayEncoder接口
+func (e *floatArrayEncoder) AppendArray(v zapcore.ArrayMarshaler) error {
+	return e.ArrayEncoder.AppendArray(floatArray{ArrayMarshaler: v, n: e.n})
+}
@@ -0,0 +1,102 @@
+package hlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// stats 测试用的反射字段
+type stats struct {
+	Ratio float64 `json:"ratio"`
+	Count int     `json:"count"`
+}
+
+// TestNonFiniteFloat 测试NaN和Inf替换后日志仍是合法json
+func TestNonFiniteFloat(t *testing.T) {
+	os.MkdirAll("./log", 0755)
+	logFile := "./log/non_finite_float.log"
+	os.Remove(logFile)
+
+	logger, err := NewZapLogger(LoggerConfig{
+		Level:         "info",
+		OutputPath:    []string{logFile},
+		Encoder:       "json",
+		EncoderConfig: &EncoderConfig{NonFiniteFloat: "null"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+	logger.With(zap.Float64("base", math.Inf(-1))).Info("calc",
+		zap.Float64("nan", math.NaN()),
+		zap.Float32("inf", float32(math.Inf(1))),
+		zap.Float64("ok", 1.5),
+		zap.Float64s("list", []float64{1, math.NaN()}),
+		zap.Any("stats", stats{Ratio: math.NaN(), Count: 2}),
+		zap.Any("m", map[string]float64{"a": math.Inf(1)}),
+	)
+
+	data, err := SyncAndRead(logger, logFile)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	var line map[string]interface{}
+	if err := json.Unmarshal(bytes.TrimSpace(data), &line); err != nil {
+		t.Fatalf("log line is not valid json: %v\n%s", err, data)
+	}
+	for _, key := range []string{"base", "nan", "inf"} {
+		if v, ok := line[key]; !ok || v != nil {
+			t.Errorf("expected %s to be null, got %v", key, v)
+		}
+	}
+	if line["ok"] != 1.5 {
+		t.Errorf("finite float changed: %v", line["ok"])
+	}
+	if list, _ := line["list"].([]interface{}); len(list) != 2 || list[0] != 1.0 || list[1] != nil {
+		t.Errorf("unexpected list: %v", line["list"])
+	}
+	if s, _ := line["stats"].(map[string]interface{}); s == nil || s["ratio"] != nil || s["count"] != 2.0 {
+		t.Errorf("unexpected stats: %v", line["stats"])
+	}
+	if m, _ := line["m"].(map[string]interface{}); m == nil || m["a"] != nil {
+		t.Errorf("unexpected map: %v", line["m"])
+	}
+}
+
+// TestNonFiniteFloatRotating 测试轮转logger使用字符串替换NaN
+func TestNonFiniteFloatRotating(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "app.log")
+	logger, err := NewRotatingLogger(RotateConfig{
+		Level:         "info",
+		Encoder:       "json",
+		OutputType:    "file",
+		RotateStyle:   "rename",
+		Filename:      logFile,
+		EncoderConfig: &EncoderConfig{NonFiniteFloat: "invalid"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create rotating logger: %v", err)
+	}
+	defer logger.Close()
+	logger.Info("calc", zap.Any("stats", map[string]float64{"ratio": math.NaN()}))
+
+	data, err := SyncAndRead(logger, logFile)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	var line struct {
+		Stats map[string]interface{} `json:"stats"`
+	}
+	if err := json.Unmarshal(bytes.TrimSpace(data), &line); err != nil {
+		t.Fatalf("log line is not valid json: %v\n%s", err, data)
+	}
+	if line.Stats["ratio"] != "invalid" {
+		t.Errorf("expected sentinel, got %s", data)
+	}
+}
@@ -2,9 +2,13 @@ package hlog
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -464,3 +468,494 @@ func TestGormErrorLogging(t *testing.T) {
 		t.Logf("GORM error log file created successfully: %s", logFile)
 	}
 }
+
+// syncCountLogger 记录Sync调用次数的HLogger
+type syncCountLogger struct {
+	HLogger
+	syncs int
+}
+
+func (l *syncCountLogger) Sync() error {
+	l.syncs++
+	return nil
+}
+
+// TestGormFlushOnError 测试SQL错误后刷新底层logger
+func TestGormFlushOnError(t *testing.T) {
+	// 确保日志目录存在
+	os.MkdirAll("./log", 0755)
+
+	hlogger, err := NewZapLogger(LoggerConfig{
+		Level:      "info",
+		OutputPath: []string{"./log/gorm_flush_test.log"},
+		Encoder:    "json",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create hlog logger: %v", err)
+	}
+	defer hlogger.Close()
+
+	counter := &syncCountLogger{HLogger: hlogger}
+	gormLogger := NewGormLogger(counter, &logger.Config{
+		SlowThreshold: 200 * time.Millisecond,
+		LogLevel:      logger.Info,
+	}, WithFlushOnError(true))
+
+	// 正常SQL不刷新
+	gormLogger.Trace(context.Background(), time.Now(), func() (string, int64) {
+		return "SELECT * FROM users", 1
+	}, nil)
+	if counter.syncs != 0 {
+		t.Errorf("expected no flush after normal trace, got %d", counter.syncs)
+	}
+
+	// SQL错误后刷新
+	gormLogger.Trace(context.Background(), time.Now(), func() (string, int64) {
+		return "SELECT * FROM non_existent_table", 0
+	}, fmt.Errorf("table does not exist"))
+	if counter.syncs != 1 {
+		t.Errorf("expected one flush after error trace, got %d", counter.syncs)
+	}
+}
+
+// TestGormConsoleFormat 测试console编码下的SQL日志格式
+func TestGormConsoleFormat(t *testing.T) {
+	// 确保日志目录存在
+	os.MkdirAll("./log", 0755)
+
+	cases := []struct {
+		name       string
+		singleLine bool
+		want       string
+	}{
+		{"MultiLine", false, "SQL\n["},
+		{"SingleLine", true, "SQL ["},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			logFile := "./log/gorm_console_" + c.name + ".log"
+			os.Remove(logFile)
+
+			hlogger, err := NewZapLogger(LoggerConfig{
+				Level:      "info",
+				OutputPath: []string{logFile},
+				Encoder:    "console",
+			})
+			if err != nil {
+				t.Fatalf("Failed to create hlog logger: %v", err)
+			}
+
+			gormLogger := NewGormLogger(hlogger, &logger.Config{
+				SlowThreshold: 200 * time.Millisecond,
+				LogLevel:      logger.Info,
+			}, WithSingleLineSQL(c.singleLine))
+			gormLogger.Trace(context.Background(), time.Now(), func() (string, int64) {
+				return "SELECT * FROM users", 1
+			}, nil)
+			hlogger.Close()
+
+			data, err := os.ReadFile(logFile)
+			if err != nil {
+				t.Fatalf("Failed to read log file: %v", err)
+			}
+			content := string(data)
+			if strings.Contains(content, "\r") {
+				t.Errorf("unexpected carriage return in output: %q", content)
+			}
+			if !strings.Contains(content, c.want) || !strings.Contains(content, "[rows: 1] SELECT * FROM users") {
+				t.Errorf("unexpected console output: %q", content)
+			}
+		})
+	}
+}
+
+// TestGormSummary 测试周期输出SQL执行汇总
+func TestGormSummary(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	hlogger := &zapLogger{logger: zap.New(core)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	gormLogger := NewGormLogger(hlogger, &logger.Config{
+		SlowThreshold: 100 * time.Millisecond,
+		LogLevel:      logger.Warn,
+	}, WithSummary(ctx, 200*time.Millisecond))
+
+	for i := 0; i < 3; i++ {
+		gormLogger.Trace(context.Background(), time.Now(), func() (string, int64) {
+			return "SELECT * FROM users", 1
+		}, nil)
+	}
+	gormLogger.Trace(context.Background(), time.Now().Add(-300*time.Millisecond), func() (string, int64) {
+		return "SELECT * FROM large_table", 100
+	}, nil)
+	gormLogger.Trace(context.Background(), time.Now(), func() (string, int64) {
+		return "SELECT * FROM non_existent_table", 0
+	}, fmt.Errorf("table does not exist"))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for logs.FilterMessage("SQL summary").Len() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	summaries := logs.FilterMessage("SQL summary").All()
+	if len(summaries) == 0 {
+		t.Fatal("expected a summary line")
+	}
+
+	fields := summaries[0].ContextMap()
+	if fields["queries"] != int64(5) || fields["slow"] != int64(1) || fields["errors"] != int64(1) {
+		t.Errorf("unexpected summary counts: %v", fields)
+	}
+	if elapsed, _ := fields["total_elapsed"].(time.Duration); elapsed < 300*time.Millisecond {
+		t.Errorf("expected total_elapsed >= 300ms, got %v", fields["total_elapsed"])
+	}
+}
+
+// fakeGormMetrics 记录推送指标的GormMetrics
+type fakeGormMetrics struct {
+	counters  map[string]int
+	durations map[string][]time.Duration
+}
+
+func (m *fakeGormMetrics) IncCounter(name string) {
+	m.counters[name]++
+}
+
+func (m *fakeGormMetrics) ObserveDuration(name string, d time.Duration) {
+	m.durations[name] = append(m.durations[name], d)
+}
+
+// TestGormMetrics 测试慢查询推送耗时和计数指标
+func TestGormMetrics(t *testing.T) {
+	core, _ := observer.New(zapcore.InfoLevel)
+	metrics := &fakeGormMetrics{counters: map[string]int{}, durations: map[string][]time.Duration{}}
+	gormLogger := NewGormLogger(&zapLogger{logger: zap.New(core)}, &logger.Config{
+		SlowThreshold: 100 * time.Millisecond,
+		LogLevel:      logger.Warn,
+	}, WithMetrics(metrics))
+
+	gormLogger.Trace(context.Background(), time.Now().Add(-300*time.Millisecond), func() (string, int64) {
+		return "SELECT * FROM large_table", 100
+	}, nil)
+
+	if metrics.counters[MetricSQLQueries] != 1 || metrics.counters[MetricSQLSlow] != 1 || metrics.counters[MetricSQLErrors] != 0 {
+		t.Errorf("unexpected counters: %v", metrics.counters)
+	}
+	if d := metrics.durations[MetricSQLDuration]; len(d) != 1 || d[0] < 300*time.Millisecond {
+		t.Errorf("unexpected duration observations: %v", d)
+	}
+}
+
+// TestGormSlowThresholdDisabled 测试SlowThreshold为0时日志和指标都不判定慢查询
+func TestGormSlowThresholdDisabled(t *testing.T) {
+	core, recorded := observer.New(zapcore.InfoLevel)
+	metrics := &fakeGormMetrics{counters: map[string]int{}, durations: map[string][]time.Duration{}}
+	gormLogger := NewGormLogger(&zapLogger{logger: zap.New(core)}, &logger.Config{
+		LogLevel: logger.Warn,
+	}, WithMetrics(metrics))
+
+	gormLogger.Trace(context.Background(), time.Now().Add(-300*time.Millisecond), func() (string, int64) {
+		return "SELECT * FROM large_table", 100
+	}, nil)
+
+	if recorded.Len() != 0 {
+		t.Errorf("expected no slow query log, got %v", recorded.All()[0].Message)
+	}
+	if metrics.counters[MetricSQLQueries] != 1 || metrics.counters[MetricSQLSlow] != 0 {
+		t.Errorf("unexpected counters: %v", metrics.counters)
+	}
+}
+
+// TestGormSourceLocation 测试SQL日志输出发起查询的代码位置
+func TestGormSourceLocation(t *testing.T) {
+	core, recorded := observer.New(zapcore.InfoLevel)
+	gormLogger := NewGormLogger(&zapLogger{logger: zap.New(core)}, &logger.Config{
+		SlowThreshold: time.Second,
+		LogLevel:      logger.Info,
+	}, WithSourceLocation(true))
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: gormLogger,
+	})
+	if err != nil {
+		t.Fatalf("Failed to connect to SQLite: %v", err)
+	}
+
+	var n int
+	db.Raw("SELECT 1").Scan(&n)
+
+	entries := recorded.FilterMessage("SQL").All()
+	if len(entries) == 0 {
+		t.Fatal("expected SQL entries")
+	}
+	source, _ := entries[len(entries)-1].ContextMap()["source"].(string)
+	if !strings.Contains(source, "gorm_logger_test.go:") {
+		t.Errorf("unexpected source: %q", source)
+	}
+}
+
+// TestGormRecordNotFound 测试Info级别下记录未找到错误按IgnoreRecordNotFoundError输出
+func TestGormRecordNotFound(t *testing.T) {
+	for _, ignore := range []bool{false, true} {
+		core, recorded := observer.New(zapcore.InfoLevel)
+		gormLogger := NewGormLogger(&zapLogger{logger: zap.New(core)}, &logger.Config{
+			SlowThreshold:             time.Second,
+			LogLevel:                  logger.Info,
+			IgnoreRecordNotFoundError: ignore,
+		})
+
+		gormLogger.Trace(context.Background(), time.Now(), func() (string, int64) {
+			return "SELECT * FROM users WHERE id = 999", 0
+		}, gorm.ErrRecordNotFound)
+
+		entries := recorded.All()
+		if len(entries) != 1 {
+			t.Fatalf("ignore=%v: expected 1 entry, got %d", ignore, len(entries))
+		}
+		entry := entries[0]
+		if !ignore {
+			if entry.Level != zapcore.ErrorLevel || entry.Message != "SQL Error" {
+				t.Errorf("ignore=false: unexpected entry %s %q", entry.Level, entry.Message)
+			}
+			continue
+		}
+		if entry.Level != zapcore.InfoLevel || entry.Message != "SQL" {
+			t.Errorf("ignore=true: unexpected entry %s %q", entry.Level, entry.Message)
+		}
+		if notFound, _ := entry.ContextMap()["not_found"].(bool); !notFound {
+			t.Errorf("ignore=true: expected not_found field, got %v", entry.ContextMap())
+		}
+	}
+}
+
+// TestGormParameterizedQueries 测试开启ParameterizedQueries后日志中不出现参数值
+func TestGormParameterizedQueries(t *testing.T) {
+	core, recorded := observer.New(zapcore.InfoLevel)
+	gormLogger := NewGormLogger(&zapLogger{logger: zap.New(core)}, &logger.Config{
+		SlowThreshold:        time.Second,
+		LogLevel:             logger.Info,
+		ParameterizedQueries: true,
+	})
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: gormLogger,
+	})
+	if err != nil {
+		t.Fatalf("Failed to connect to SQLite: %v", err)
+	}
+
+	type Account struct {
+		ID    uint `gorm:"primaryKey"`
+		Email string
+		Token string
+	}
+	if err := db.AutoMigrate(&Account{}); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	recorded.TakeAll()
+
+	db.Create(&Account{Email: "alice@example.com", Token: "s3cr3t-token"})
+
+	entries := recorded.All()
+	if len(entries) == 0 {
+		t.Fatal("expected SQL entries")
+	}
+	for _, entry := range entries {
+		fields := entry.ContextMap()
+		sql, _ := fields["sql"].(string)
+		if strings.Contains(sql, "alice@example.com") || strings.Contains(sql, "s3cr3t-token") {
+			t.Errorf("literal value leaked into sql: %s", sql)
+		}
+		if args, _ := fields["args"].(int64); args != 2 {
+			t.Errorf("expected 2 args, got %v in %s", fields["args"], sql)
+		}
+	}
+}
+
+// TestGormRowsKind 测试按语句类型区分行数字段名
+func TestGormRowsKind(t *testing.T) {
+	cases := []struct {
+		rowsKind bool
+		sql      string
+		key      string
+	}{
+		{false, "SELECT * FROM users", "rows"},
+		{false, "UPDATE users SET age = 1", "rows"},
+		{true, "SELECT * FROM users", "rows_returned"},
+		{true, "  select id FROM users", "rows_returned"},
+		{true, "UPDATE users SET age = 1", "rows_affected"},
+		{true, "INSERT INTO users (name) VALUES ('a')", "rows_affected"},
+	}
+	for _, c := range cases {
+		core, recorded := observer.New(zapcore.InfoLevel)
+		gormLogger := NewGormLogger(&zapLogger{logger: zap.New(core)}, &logger.Config{
+			SlowThreshold: time.Second,
+			LogLevel:      logger.Info,
+		}, WithRowsKind(c.rowsKind))
+
+		gormLogger.Trace(context.Background(), time.Now(), func() (string, int64) {
+			return c.sql, 3
+		}, nil)
+
+		entries := recorded.All()
+		if len(entries) != 1 {
+			t.Fatalf("expected 1 entry, got %d", len(entries))
+		}
+		if rows, ok := entries[0].ContextMap()[c.key]; !ok || rows != int64(3) {
+			t.Errorf("rowsKind=%v sql=%q: expected %s=3, got %v", c.rowsKind, c.sql, c.key, entries[0].ContextMap())
+		}
+	}
+}
+
+// TestGormVerySlowQuery 测试超过慢查询阈值倍数时升级为Error级别
+func TestGormVerySlowQuery(t *testing.T) {
+	cases := []struct {
+		multiplier float64
+		elapsed    time.Duration
+		level      zapcore.Level
+		msg        string
+	}{
+		{0, 100 * time.Millisecond, zapcore.WarnLevel, "SLOW SQL"},
+		{5, 20 * time.Millisecond, zapcore.WarnLevel, "SLOW SQL"},
+		{5, 100 * time.Millisecond, zapcore.ErrorLevel, "VERY SLOW SQL"},
+	}
+	for _, c := range cases {
+		core, recorded := observer.New(zapcore.InfoLevel)
+		gormLogger := NewGormLogger(&zapLogger{logger: zap.New(core)}, &logger.Config{
+			SlowThreshold: 10 * time.Millisecond,
+			LogLevel:      logger.Info,
+		}, WithVerySlowMultiplier(c.multiplier))
+
+		gormLogger.Trace(context.Background(), time.Now().Add(-c.elapsed), func() (string, int64) {
+			return "SELECT * FROM users", 1
+		}, nil)
+
+		entries := recorded.All()
+		if len(entries) != 1 {
+			t.Fatalf("expected 1 entry, got %d", len(entries))
+		}
+		if entries[0].Level != c.level || entries[0].Message != c.msg {
+			t.Errorf("multiplier=%v elapsed=%v: expected %s %q, got %s %q",
+				c.multiplier, c.elapsed, c.level, c.msg, entries[0].Level, entries[0].Message)
+		}
+	}
+}
+
+// TestGormSilent 测试Silent级别下任何方法都不输出日志
+func TestGormSilent(t *testing.T) {
+	// 确保日志目录存在
+	os.MkdirAll("./log", 0755)
+
+	logFile := "./log/gorm_silent_test.log"
+	os.Remove(logFile)
+	hlogger, err := NewZapLogger(LoggerConfig{
+		Level:      "debug",
+		OutputPath: []string{logFile},
+		Encoder:    "json",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create hlog logger: %v", err)
+	}
+	defer hlogger.Close()
+
+	gormLogger := NewGormLogger(hlogger, &logger.Config{
+		SlowThreshold: 10 * time.Millisecond,
+		LogLevel:      logger.Info,
+	}).LogMode(logger.Silent)
+
+	ctx := context.Background()
+	gormLogger.Info(ctx, "info %d", 1)
+	gormLogger.Warn(ctx, "warn %d", 2)
+	gormLogger.Error(ctx, "error %d", 3)
+	gormLogger.Trace(ctx, time.Now().Add(-time.Second), func() (string, int64) {
+		return "SELECT * FROM non_existent_table", 0
+	}, fmt.Errorf("table does not exist"))
+	hlogger.Sync()
+
+	data, err := os.ReadFile(logFile)
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	if len(data) != 0 {
+		t.Errorf("expected empty log file, got %q", data)
+	}
+}
+
+// TestGormWarnLevelTrace 测试Warn级别下仍输出慢查询和SQL错误
+func TestGormWarnLevelTrace(t *testing.T) {
+	core, recorded := observer.New(zapcore.InfoLevel)
+	gormLogger := NewGormLogger(&zapLogger{logger: zap.New(core)}, &logger.Config{
+		SlowThreshold: 10 * time.Millisecond,
+		LogLevel:      logger.Warn,
+	})
+
+	ctx := context.Background()
+	gormLogger.Trace(ctx, time.Now(), func() (string, int64) {
+		return "SELECT 1", 1
+	}, nil)
+	gormLogger.Trace(ctx, time.Now().Add(-time.Second), func() (string, int64) {
+		return "SELECT 2", 1
+	}, nil)
+	gormLogger.Trace(ctx, time.Now(), func() (string, int64) {
+		return "SELECT * FROM non_existent_table", 0
+	}, fmt.Errorf("table does not exist"))
+
+	var msgs []string
+	for _, entry := range recorded.All() {
+		msgs = append(msgs, entry.Message)
+	}
+	if strings.Join(msgs, ",") != "SLOW SQL,SQL Error" {
+		t.Errorf("unexpected entries: %v", msgs)
+	}
+}
+
+// TestGormErrorRateLimit 测试相同SQL错误在时间窗口内限流并在窗口结束时输出丢弃条数
+func TestGormErrorRateLimit(t *testing.T) {
+	hlogger, logs := NewObservedLogger()
+	gormLogger := NewGormLogger(hlogger, &logger.Config{
+		SlowThreshold: time.Second,
+		LogLevel:      logger.Warn,
+	}, WithErrorRateLimit(3, 100*time.Millisecond))
+
+	queryErr := errors.New("no such table: users")
+	for i := 0; i < 1000; i++ {
+		gormLogger.Trace(context.Background(), time.Now(), func() (string, int64) {
+			return fmt.Sprintf("SELECT * FROM users WHERE id = %d AND name = 'user-%d'", i, i), 0
+		}, queryErr)
+	}
+	// 不同的错误单独计数
+	gormLogger.Trace(context.Background(), time.Now(), func() (string, int64) {
+		return "SELECT * FROM orders", 0
+	}, queryErr)
+
+	if n := logs.FilterMessage("SQL Error").Len(); n != 4 {
+		t.Fatalf("expected 4 error entries, got %d", n)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for logs.FilterMessage("SQL Error suppressed").Len() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	suppressed := logs.FilterMessage("SQL Error suppressed").All()
+	if len(suppressed) != 1 {
+		t.Fatalf("expected 1 suppression entry, got %d", len(suppressed))
+	}
+	fields := suppressed[0].ContextMap()
+	if fields["suppressed"] != int64(997) || fields["sql"] != "SELECT * FROM users WHERE id = ? AND name = ?" {
+		t.Errorf("unexpected suppression fields: %v", fields)
+	}
+}
+
+func TestNormalizeSQL(t *testing.T) {
+	cases := map[string]string{
+		"SELECT * FROM t1 WHERE id = 42":                    "SELECT * FROM t1 WHERE id = ?",
+		"UPDATE `t` SET name = 'it''s', score = 1.5":        "UPDATE `t` SET name = ?, score = ?",
+		`SELECT "col1" FROM users WHERE id = $1 LIMIT 10`:   `SELECT "col1" FROM users WHERE id = $1 LIMIT ?`,
+		"INSERT INTO logs (a, b) VALUES (-3, '2026-01-07')": "INSERT INTO logs (a, b) VALUES (-?, ?)",
+	}
+	for in, want := range cases {
+		if got := normalizeSQL(in); got != want {
+			t.Errorf("normalizeSQL(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
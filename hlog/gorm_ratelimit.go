@@ -0,0 +1,151 @@
+package hlog
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// gormLimiter 按归一化SQL和错误限制SQL错误日志的输出次数, LogMode派生的logger共享同一个限流器
+type gormLimiter struct {
+	limit    int
+	interval time.Duration
+	report   func(w *limitWindow)
+
+	mu      sync.Mutex
+	windows map[string]*limitWindow
+}
+
+// limitWindow 一个SQL和错误组合在当前时间窗口内的计数
+type limitWindow struct {
+	sql        string // 归一化后的SQL
+	err        string
+	count      int
+	suppressed int
+}
+
+// WithErrorRateLimit 限制相同SQL错误的输出次数, 每个interval内同一组合最多输出limit条, 其余丢弃
+//
+// SQL中的字符串和数字字面量会被替换为?, 仅参数不同的SQL视为同一组合; 时间窗口结束时若有丢弃,
+// 以Warn级别输出一条"SQL Error suppressed"及丢弃条数
+func WithErrorRateLimit(limit int, interval time.Duration) GormOption {
+	return func(g *gormLogger) {
+		if limit <= 0 || interval <= 0 {
+			return
+		}
+		g.limiter = &gormLimiter{
+			limit:    limit,
+			interval: interval,
+			report:   g.reportSuppressed,
+			windows:  make(map[string]*limitWindow),
+		}
+	}
+}
+
+// allow 记录一次错误, 返回当前时间窗口内是否仍可输出
+func (l *gormLimiter) allow(sql string, err error) bool {
+	sql = normalizeSQL(sql)
+	errMsg := err.Error()
+	key := sql + "\x00" + errMsg
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	w, ok := l.windows[key]
+	if !ok {
+		w = &limitWindow{sql: sql, err: errMsg}
+		l.windows[key] = w
+		time.AfterFunc(l.interval, func() { l.close(key, w) })
+	}
+	w.count++
+	if w.count <= l.limit {
+		return true
+	}
+	w.suppressed++
+	return false
+}
+
+// close 结束一个时间窗口, 有丢弃时输出汇总
+func (l *gormLimiter) close(key string, w *limitWindow) {
+	l.mu.Lock()
+	delete(l.windows, key)
+	suppressed := w.suppressed
+	l.mu.Unlock()
+
+	if suppressed > 0 {
+		l.report(w)
+	}
+}
+
+// reportSuppressed 输出一个时间窗口内被丢弃的SQL错误条数
+func (g *gormLogger) reportSuppressed(w *limitWindow) {
+	if g.isConsole() {
+		g.Logger.Warn(fmt.Sprintf("SQL Error suppressed %d times in %v: %s\n%s", w.suppressed, g.limiter.interval, w.err, w.sql))
+		return
+	}
+	g.Logger.Warn("SQL Error suppressed",
+		zap.String("sql", w.sql),
+		zap.String("error", w.err),
+		zap.Int("suppressed", w.suppressed),
+		zap.Duration("interval", g.limiter.interval),
+	)
+}
+
+// normalizeSQL 将SQL中引号内的字符串和独立的数字字面量替换为?, 保留标识符和$n占位符
+func normalizeSQL(sql string) string {
+	var b strings.Builder
+	b.Grow(len(sql))
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == '\'':
+			// 跳过字符串字面量, ''为转义的单引号
+			for i++; i < len(sql); i++ {
+				if sql[i] == '\'' {
+					if i+1 < len(sql) && sql[i+1] == '\'' {
+						i++
+						continue
+					}
+					break
+				}
+			}
+			b.WriteByte('?')
+		case c == '"' || c == '`':
+			// 带引号的标识符原样保留
+			j := strings.IndexByte(sql[i+1:], c)
+			if j < 0 {
+				b.WriteString(sql[i:])
+				return b.String()
+			}
+			b.WriteString(sql[i : i+j+2])
+			i += j + 1
+		case c == '$' && i+1 < len(sql) && isDigit(sql[i+1]):
+			b.WriteByte(c)
+			for i+1 < len(sql) && isDigit(sql[i+1]) {
+				i++
+				b.WriteByte(sql[i])
+			}
+		case isDigit(c) && (i == 0 || !isIdentChar(sql[i-1])):
+			for i+1 < len(sql) && (isDigit(sql[i+1]) || sql[i+1] == '.') {
+				i++
+			}
+			b.WriteByte('?')
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// isDigit 是否为数字字符
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// isIdentChar 是否为标识符中可出现的字符
+func isIdentChar(c byte) bool {
+	return isDigit(c) || c == '_' || c == '.' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,61 @@
+package hlog
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// gormStats 周期汇总的SQL统计计数, LogMode派生的logger共享同一份计数
+type gormStats struct {
+	queries atomic.Int64
+	elapsed atomic.Int64 // 累计耗时, 纳秒
+	slow    atomic.Int64
+	errors  atomic.Int64
+}
+
+// record 记录一次SQL执行
+func (s *gormStats) record(elapsed time.Duration, slow, failed bool) {
+	s.queries.Add(1)
+	s.elapsed.Add(int64(elapsed))
+	if slow {
+		s.slow.Add(1)
+	}
+	if failed {
+		s.errors.Add(1)
+	}
+}
+
+// WithSummary 开启SQL执行汇总, 每隔interval以Info级别输出一次查询数、总耗时、慢查询数和错误数并清零
+//
+// 汇总协程在ctx结束时退出
+func WithSummary(ctx context.Context, interval time.Duration) GormOption {
+	return func(g *gormLogger) {
+		if interval <= 0 {
+			return
+		}
+		g.stats = &gormStats{}
+		go g.runSummary(ctx, interval)
+	}
+}
+
+// runSummary 周期输出汇总日志
+func (g *gormLogger) runSummary(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			g.Logger.Info("SQL summary",
+				zap.Int64("queries", g.stats.queries.Swap(0)),
+				zap.Duration("total_elapsed", time.Duration(g.stats.elapsed.Swap(0))),
+				zap.Int64("slow", g.stats.slow.Swap(0)),
+				zap.Int64("errors", g.stats.errors.Swap(0)),
+			)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
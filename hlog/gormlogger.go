@@ -15,11 +15,91 @@ import (
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
+	"runtime"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// GORM日志适配器推送的指标名称
+const (
+	MetricSQLQueries  = "sql_queries_total" // 计数: SQL执行次数
+	MetricSQLSlow     = "sql_slow_total"    // 计数: 慢查询次数
+	MetricSQLErrors   = "sql_errors_total"  // 计数: SQL错误次数
+	MetricSQLDuration = "sql_duration"      // 耗时: 每次SQL执行耗时
+)
+
+// GormOption GORM日志适配器的可选配置
+type GormOption func(g *gormLogger)
+
+// WithFlushOnError 记录SQL错误后立即刷新底层HLogger, 默认关闭以保证吞吐
+func WithFlushOnError(flush bool) GormOption {
+	return func(g *gormLogger) {
+		g.FlushOnError = flush
+	}
+}
+
+// WithSingleLineSQL console编码下将SQL与耗时等信息输出在同一行, 便于grep
+func WithSingleLineSQL(singleLine bool) GormOption {
+	return func(g *gormLogger) {
+		g.SingleLineSQL = singleLine
+	}
+}
+
+// WithRowsKind 按语句类型区分行数字段: SELECT输出rows_returned, 其余输出rows_affected, 默认关闭时统一输出rows
+func WithRowsKind(enable bool) GormOption {
+	return func(g *gormLogger) {
+		g.RowsKind = enable
+	}
+}
+
+// WithVerySlowMultiplier 耗时超过SlowThreshold*multiplier的慢查询升级为Error级别输出, 为0时不升级
+func WithVerySlowMultiplier(multiplier float64) GormOption {
+	return func(g *gormLogger) {
+		g.VerySlowMultiplier = multiplier
+	}
+}
+
+// WithMetrics 设置指标推送, 每次Trace都会推送执行次数、耗时以及慢查询和错误计数
+func WithMetrics(metrics GormMetrics) GormOption {
+	return func(g *gormLogger) {
+		g.metrics = metrics
+	}
+}
+
+// WithSourceLocation 在SQL日志中输出发起查询的代码位置(file:line), json编码为source字段, console编码追加在行尾
+//
+// 每次输出都需要遍历调用栈, 默认关闭
+func WithSourceLocation(enable bool) GormOption {
+	return func(g *gormLogger) {
+		g.SourceLocation = enable
+	}
+}
+
+// gormLoggerFile 本文件路径, 查找SQL发起位置时跳过
+var gormLoggerFile = func() string {
+	_, file, _, _ := runtime.Caller(0)
+	return file
+}()
+
+// sqlSourceLocation 返回调用栈中第一个不属于gorm及本适配器的位置
+func sqlSourceLocation() string {
+	var pcs [32]uintptr
+	n := runtime.Callers(3, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if frame.File != gormLoggerFile && !strings.Contains(frame.File, "gorm.io/") {
+			return frame.File + ":" + strconv.Itoa(frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
 // NewGormLogger 创建一个新的GORM日志适配器
-func NewGormLogger(hlogger HLogger, config *logger.Config) logger.Interface {
+func NewGormLogger(hlogger HLogger, config *logger.Config, opts ...GormOption) logger.Interface {
 	if config == nil {
 		// 使用默认配置
 		config = &logger.Config{
@@ -35,6 +115,7 @@ func NewGormLogger(hlogger HLogger, config *logger.Config) logger.Interface {
 		SlowThreshold:             config.SlowThreshold,
 		LogLevel:                  config.LogLevel,
 		IgnoreRecordNotFoundError: config.IgnoreRecordNotFoundError,
+		ParameterizedQueries:      config.ParameterizedQueries,
 		Context:                   context.Background(),
 	}
 
@@ -48,6 +129,10 @@ func NewGormLogger(hlogger HLogger, config *logger.Config) logger.Interface {
 		}
 	}
 
+	for _, opt := range opts {
+		opt(gLogger)
+	}
+
 	return gLogger
 }
 
@@ -62,7 +147,7 @@ func (g *gormLogger) LogMode(level logger.LogLevel) logger.Interface {
 func (g *gormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
 	if g.LogLevel >= logger.Info {
 		formattedMsg := fmt.Sprintf(msg, data...)
-		g.Logger.Info(formattedMsg)
+		g.Logger.WithContext(ctx).Info(formattedMsg)
 	}
 }
 
@@ -70,7 +155,7 @@ func (g *gormLogger) Info(ctx context.Context, msg string, data ...interface{})
 func (g *gormLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
 	if g.LogLevel >= logger.Warn {
 		formattedMsg := fmt.Sprintf(msg, data...)
-		g.Logger.Warn(formattedMsg)
+		g.Logger.WithContext(ctx).Warn(formattedMsg)
 	}
 }
 
@@ -78,69 +163,192 @@ func (g *gormLogger) Warn(ctx context.Context, msg string, data ...interface{})
 func (g *gormLogger) Error(ctx context.Context, msg string, data ...interface{}) {
 	if g.LogLevel >= logger.Error {
 		formattedMsg := fmt.Sprintf(msg, data...)
-		g.Logger.Error(formattedMsg)
+		g.Logger.WithContext(ctx).Error(formattedMsg)
+	}
+}
+
+// ParamsFilter 实现gorm.ParamsFilter, 开启ParameterizedQueries时丢弃参数, 日志中保留占位符
+func (g *gormLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	if g.ParameterizedQueries {
+		return sql, nil
 	}
+	return sql, params
+}
+
+// isSlow 判断耗时是否超过慢查询阈值, 与gorm一致SlowThreshold为0时不判定慢查询; 日志、汇总和指标共用
+func (g *gormLogger) isSlow(elapsed time.Duration) bool {
+	return g.SlowThreshold != 0 && elapsed > g.SlowThreshold
 }
 
 // Trace 记录SQL执行追踪日志
 func (g *gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
-	if g.LogLevel < logger.Info {
-		return
+	elapsed := time.Since(begin)
+	slow := g.isSlow(elapsed)
+	if g.stats != nil || g.metrics != nil {
+		failed := err != nil && (!g.IgnoreRecordNotFoundError || !errors.Is(err, gorm.ErrRecordNotFound))
+		if g.stats != nil {
+			g.stats.record(elapsed, slow, failed)
+		}
+		if g.metrics != nil {
+			g.pushMetrics(elapsed, slow, failed)
+		}
 	}
 
-	elapsed := time.Since(begin)
-	var consoleFlag bool
-	if g.config != nil && g.config.Encoder == "console" {
-		consoleFlag = true
+	// Silent及以下不输出任何SQL日志, 其余级别由下方各分支自行判断
+	if g.LogLevel <= logger.Silent {
+		return
 	}
-	if !consoleFlag && g.rotateConfig != nil && g.rotateConfig.Encoder == "console" {
-		consoleFlag = true
+	log := g.Logger.WithContext(ctx)
+	var source string
+	if g.SourceLocation {
+		source = sqlSourceLocation()
 	}
+
+	consoleFlag := g.isConsole()
 	switch {
 	case err != nil && g.LogLevel >= logger.Error && (!g.IgnoreRecordNotFoundError || !errors.Is(err, gorm.ErrRecordNotFound)):
-		// 记录错误
+		// 记录错误, 开启限流时丢弃超出次数的重复错误
 		sql, rows := fc()
-		if consoleFlag {
-			g.Logger.Error(
-				fmt.Sprintf("SQL Error: %v \r\n[%v] [rows: %v] %v", err, elapsed, rows, sql),
-			)
-		} else {
-			g.Logger.Error("SQL Error",
-				zap.String("sql", sql),
-				zap.Int64("rows", rows),
-				zap.Duration("elapsed", elapsed),
-				zap.Error(err),
-			)
+		if g.limiter != nil && !g.limiter.allow(sql, err) {
+			return
+		}
+		g.logSQL(log.Error, consoleFlag, "SQL Error", fmt.Sprintf("SQL Error: %v", err), elapsed, rows, sql, source,
+			zap.Error(err),
+		)
+		if g.FlushOnError {
+			g.flush()
 		}
 
-	case elapsed > g.SlowThreshold && g.LogLevel >= logger.Warn:
+	case slow && g.LogLevel >= logger.Warn:
 		// 记录慢查询
 		sql, rows := fc()
-		if consoleFlag {
-			g.Logger.Warn(
-				fmt.Sprintf("SLOW SQL > %v \r\n[%v] [rows: %v] %v", g.SlowThreshold, elapsed, rows, sql),
-			)
-		} else {
-			g.Logger.Warn("SLOW SQL",
-				zap.String("sql", sql),
-				zap.Int64("rows", rows),
-				zap.Duration("elapsed", elapsed),
-				zap.Float64("threshold_ms", g.SlowThreshold.Seconds()*1000),
-			)
+		logFn, msg, threshold := log.Warn, "SLOW SQL", g.SlowThreshold
+		if g.VerySlowMultiplier > 0 {
+			if verySlow := time.Duration(float64(g.SlowThreshold) * g.VerySlowMultiplier); elapsed > verySlow {
+				logFn, msg, threshold = log.Error, "VERY SLOW SQL", verySlow
+			}
+		}
+		fields := []zap.Field{zap.Float64("threshold_ms", threshold.Seconds()*1000)}
+		head := fmt.Sprintf("%s > %v", msg, threshold)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			fields = append(fields, zap.Bool("not_found", true))
+			head += notFoundSuffix
 		}
+		g.logSQL(logFn, consoleFlag, msg, head, elapsed, rows, sql, source, fields...)
 	case g.LogLevel == logger.Info:
 		// 记录所有SQL
 		sql, rows := fc()
-		if consoleFlag {
-			g.Logger.Info(
-				fmt.Sprintf("SQL \r\n[%v] [rows: %v] %v", elapsed, rows, sql),
-			)
-		} else {
-			g.Logger.Info("SQL",
-				zap.String("sql", sql),
-				zap.Int64("rows", rows),
-				zap.Duration("elapsed", elapsed),
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			g.logSQL(log.Info, consoleFlag, "SQL", "SQL"+notFoundSuffix, elapsed, rows, sql, source,
+				zap.Bool("not_found", true),
 			)
+			return
 		}
+		g.logSQL(log.Info, consoleFlag, "SQL", "SQL", elapsed, rows, sql, source)
 	}
 }
+
+// isConsole 底层logger是否使用console编码
+func (g *gormLogger) isConsole() bool {
+	if g.config != nil && g.config.Encoder == "console" {
+		return true
+	}
+	return g.rotateConfig != nil && g.rotateConfig.Encoder == "console"
+}
+
+// notFoundSuffix console编码下被忽略的记录未找到错误追加在消息头后的标记
+const notFoundSuffix = " (record not found)"
+
+// logSQL 按编码方式输出一条SQL日志
+//
+// json编码输出msg以及sql/rows/elapsed和fields字段; console编码将consoleHead与SQL信息拼接为消息, 不输出fields
+func (g *gormLogger) logSQL(logFn func(string, ...zap.Field), console bool, msg, consoleHead string,
+	elapsed time.Duration, rows int64, sql, source string, fields ...zap.Field) {
+	if console {
+		line := g.consoleMsg(consoleHead, elapsed, rows, sql)
+		if source != "" {
+			line += " [" + source + "]"
+		}
+		logFn(line)
+		return
+	}
+
+	all := make([]zap.Field, 0, len(fields)+5)
+	all = append(all,
+		zap.String("sql", sql),
+		zap.Int64(g.rowsKey(sql), rows),
+		zap.Duration("elapsed", elapsed),
+	)
+	if g.ParameterizedQueries {
+		all = append(all, zap.Int("args", countPlaceholders(sql)))
+	}
+	all = append(all, fields...)
+	if source != "" {
+		all = append(all, zap.String("source", source))
+	}
+	logFn(msg, all...)
+}
+
+// countPlaceholders 统计SQL中引号外的参数占位符数量, 支持?与$n两种形式
+func countPlaceholders(sql string) int {
+	var (
+		count int
+		quote byte
+	)
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '?':
+			count++
+		case c == '$' && i+1 < len(sql) && sql[i+1] >= '0' && sql[i+1] <= '9':
+			count++
+		}
+	}
+	return count
+}
+
+// pushMetrics 推送一次SQL执行的指标
+func (g *gormLogger) pushMetrics(elapsed time.Duration, slow, failed bool) {
+	g.metrics.IncCounter(MetricSQLQueries)
+	g.metrics.ObserveDuration(MetricSQLDuration, elapsed)
+	if slow {
+		g.metrics.IncCounter(MetricSQLSlow)
+	}
+	if failed {
+		g.metrics.IncCounter(MetricSQLErrors)
+	}
+}
+
+// consoleMsg 生成console编码下的SQL日志消息
+//
+// 默认格式为 "<head>\n[elapsed] [rows: n] sql", 开启SingleLineSQL后换行替换为空格, 开启RowsKind后rows按语句类型命名
+func (g *gormLogger) consoleMsg(head string, elapsed time.Duration, rows int64, sql string) string {
+	sep := "\n"
+	if g.SingleLineSQL {
+		sep = " "
+	}
+	return fmt.Sprintf("%s%s[%v] [%s: %v] %s", head, sep, elapsed, g.rowsKey(sql), rows, sql)
+}
+
+// rowsKey 返回行数字段名, 开启RowsKind时按SQL是否以SELECT开头区分读写
+func (g *gormLogger) rowsKey(sql string) string {
+	if !g.RowsKind {
+		return "rows"
+	}
+	sql = strings.TrimSpace(sql)
+	if len(sql) >= 6 && strings.EqualFold(sql[:6], "SELECT") {
+		return "rows_returned"
+	}
+	return "rows_affected"
+}
+
+// flush 刷新底层HLogger的缓冲
+func (g *gormLogger) flush() {
+	_ = g.Logger.Sync()
+}
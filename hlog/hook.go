@@ -0,0 +1,159 @@
+package hlog
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// HookFunc 日志钩子, 在后台协程中以日志条目和结构化字段调用
+type HookFunc func(entry zapcore.Entry, fields []zapcore.Field)
+
+// hookQueueSize 等待分发给钩子的日志条目上限, 超出时丢弃
+const hookQueueSize = 1024
+
+// hook 一个已注册的钩子
+type hook struct {
+	level zapcore.Level
+	fn    HookFunc
+}
+
+// hookEvent 等待分发的日志条目
+type hookEvent struct {
+	entry  zapcore.Entry
+	fields []zapcore.Field
+}
+
+var (
+	hooksMu     sync.Mutex
+	hooks       atomic.Pointer[[]hook] // 写时复制, 写入路径无锁读取
+	hookQueue   chan hookEvent
+	hookOnce    sync.Once
+	hookDropped atomic.Int64
+)
+
+// RegisterHook 注册日志钩子, 不低于minLevel的日志在写入后交给fn处理, 可用于将错误日志转发到告警系统
+//
+// 钩子由单个后台协程按注册顺序依次调用, 不阻塞写入; 等待分发的条目超过hookQueueSize时丢弃, 可通过HookDroppedCount查看。
+// 钩子对所有NewZapLogger和NewRotatingLogger创建的logger生效, 仍受logger级别和采样限制; fn中的panic会被恢复
+func RegisterHook(minLevel string, fn HookFunc) error {
+	level, err := zapcore.ParseLevel(minLevel)
+	if err != nil {
+		return err
+	}
+	hookOnce.Do(func() {
+		hookQueue = make(chan hookEvent, hookQueueSize)
+		go dispatchHooks(hookQueue)
+	})
+
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+
+	var registered []hook
+	if p := hooks.Load(); p != nil {
+		registered = append(registered, *p...)
+	}
+	registered = append(registered, hook{level: level, fn: fn})
+	hooks.Store(&registered)
+	return nil
+}
+
+// HookDroppedCount 返回因等待队列已满而未交给钩子的日志条数
+func HookDroppedCount() int64 {
+	return hookDropped.Load()
+}
+
+// hasHook 是否有钩子需要处理level级别的日志
+func hasHook(level zapcore.Level) bool {
+	p := hooks.Load()
+	if p == nil {
+		return false
+	}
+	for _, h := range *p {
+		if level >= h.level {
+			return true
+		}
+	}
+	return false
+}
+
+// dispatchHooks 依次将队列中的条目交给级别匹配的钩子
+func dispatchHooks(queue <-chan hookEvent) {
+	for ev := range queue {
+		p := hooks.Load()
+		if p == nil {
+			continue
+		}
+		for _, h := range *p {
+			if ev.entry.Level >= h.level {
+				callHook(h.fn, ev)
+			}
+		}
+	}
+}
+
+// callHook 调用钩子并恢复其中的panic
+func callHook(fn HookFunc, ev hookEvent) {
+	defer func() {
+		recover()
+	}()
+	fn(ev.entry, ev.fields)
+}
+
+// hookCore 将日志条目交给已注册钩子的zapcore.Core
+type hookCore struct {
+	zapcore.Core
+	fields []zapcore.Field // With附加的字段
+	keys   redactKeySet    // 交给钩子前需要脱敏的字段名
+}
+
+// newHookCore 包装core, 交给钩子的字段按config.RedactKeys脱敏
+func newHookCore(core zapcore.Core, config *EncoderConfig) zapcore.Core {
+	c := &hookCore{Core: core}
+	if config != nil {
+		c.keys = newRedactKeySet(config.RedactKeys)
+	}
+	return c
+}
+
+// With 实现zapcore.Core接口
+func (c *hookCore) With(fields []zapcore.Field) zapcore.Core {
+	merged := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	merged = append(append(merged, c.fields...), fields...)
+	return &hookCore{Core: c.Core.With(fields), fields: merged, keys: c.keys}
+}
+
+// Check 实现zapcore.Core接口, 内部core照常检查, 有匹配的钩子时额外登记自身
+func (c *hookCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	ce = c.Core.Check(ent, ce)
+	if c.Enabled(ent.Level) && hasHook(ent.Level) {
+		ce = ce.AddCore(ent, hookWriter{c})
+	}
+	return ce
+}
+
+// hookWriter 只负责把条目放入钩子队列的core, 写入由内部core完成
+type hookWriter struct {
+	*hookCore
+}
+
+// Write 实现zapcore.Core接口
+func (w hookWriter) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	all := make([]zapcore.Field, 0, len(w.fields)+len(fields))
+	all = append(append(all, w.fields...), fields...)
+	if w.keys != nil {
+		all = w.keys.redactFields(all)
+	}
+	select {
+	case hookQueue <- hookEvent{entry: ent, fields: all}:
+	default:
+		hookDropped.Add(1)
+	}
+	return nil
+}
+
+// Sync 实现zapcore.Core接口, 内部core的刷新由其自身登记负责
+func (w hookWriter) Sync() error {
+	return nil
+}
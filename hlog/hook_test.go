@@ -0,0 +1,66 @@
+package hlog
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// TestRegisterHook 测试错误日志交给钩子并带上结构化字段
+func TestRegisterHook(t *testing.T) {
+	t.Cleanup(func() { hooks.Store(nil) })
+
+	type call struct {
+		entry  zapcore.Entry
+		fields map[string]interface{}
+	}
+	calls := make(chan call, 4)
+	err := RegisterHook("error", func(entry zapcore.Entry, fields []zapcore.Field) {
+		enc := zapcore.NewMapObjectEncoder()
+		for _, f := range fields {
+			f.AddTo(enc)
+		}
+		calls <- call{entry, enc.Fields}
+	})
+	if err != nil {
+		t.Fatalf("RegisterHook failed: %v", err)
+	}
+	if err := RegisterHook("loud", func(zapcore.Entry, []zapcore.Field) {}); err == nil {
+		t.Error("expected error for invalid level")
+	}
+
+	logger, err := NewZapLogger(LoggerConfig{
+		Level:         "info",
+		Encoder:       "json",
+		OutputPath:    []string{filepath.Join(t.TempDir(), "app.log")},
+		EncoderConfig: &EncoderConfig{RedactKeys: []string{"password"}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	logger.Warn("below hook level")
+	logger.With(zap.String("service", "api")).Error("db down", zap.Int("code", 500), zap.String("password", "secret"))
+
+	select {
+	case c := <-calls:
+		if c.entry.Message != "db down" || c.entry.Level != zapcore.ErrorLevel {
+			t.Errorf("unexpected entry: %+v", c.entry)
+		}
+		if c.fields["service"] != "api" || c.fields["code"] != int64(500) || c.fields["password"] != "***" {
+			t.Errorf("unexpected fields: %v", c.fields)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("hook was not invoked")
+	}
+
+	select {
+	case c := <-calls:
+		t.Errorf("unexpected hook call for %q", c.entry.Message)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -26,9 +26,44 @@ type HLogger interface {
 	Info(msg string, fields ...zap.Field)
 	Debug(msg string, fields ...zap.Field)
 	Fatal(msg string, fields ...zap.Field)
+	// With 返回附带fields的子logger，每条日志都会带上这些字段
+	With(fields ...zap.Field) HLogger
+	// Named 返回追加了名称的子logger，用于区分不同子系统的日志
+	Named(name string) HLogger
+	// WithContext 返回附带ctx中通过RegisterContextField注册的字段的子logger
+	WithContext(ctx context.Context) HLogger
+	// WithTraceContext 返回附带ctx中OpenTelemetry span的trace_id和span_id的子logger, 没有span时返回原logger
+	WithTraceContext(ctx context.Context) HLogger
+	// Sugared 返回printf风格的日志视图
+	Sugared() HSugaredLogger
+	// Sync 刷新缓冲的日志，logger之后仍可继续使用；Close在刷新后还会释放资源
+	Sync() error
 	Close() error
 }
 
+// HSugaredLogger printf风格的日志接口, 由HLogger.Sugared返回
+type HSugaredLogger interface {
+	Debugf(template string, args ...interface{})
+	Infof(template string, args ...interface{})
+	Warnf(template string, args ...interface{})
+	Errorf(template string, args ...interface{})
+	Sync() error
+	Close() error
+}
+
+// HLevelSetter 支持动态调整日志级别的logger，NewZapLogger和NewRotatingLogger创建的logger均实现该接口
+type HLevelSetter interface {
+	SetLevel(level string) error
+	SetLevelFor(level string, d time.Duration)
+	Level() string
+}
+
+// GormMetrics GORM日志适配器推送指标的接口，由使用方实现以对接Prometheus等系统
+type GormMetrics interface {
+	IncCounter(name string)
+	ObserveDuration(name string, d time.Duration)
+}
+
 // GormLoggerInterface GORM Logger接口定义
 type GormLoggerInterface interface {
 	LogMode(level int) GormLoggerInterface
@@ -44,7 +79,16 @@ type gormLogger struct {
 	config                    *LoggerConfig
 	rotateConfig              *RotateConfig
 	SlowThreshold             time.Duration   // 慢查询阈值
+	VerySlowMultiplier        float64         // 超过SlowThreshold的该倍数时按Error级别输出, 为0时不升级
 	LogLevel                  logger.LogLevel // GORM日志级别
 	IgnoreRecordNotFoundError bool            // 是否忽略记录未找到错误
+	FlushOnError              bool            // 记录SQL错误后是否立即刷新
+	SourceLocation            bool            // 是否输出发起SQL的代码位置
+	ParameterizedQueries      bool            // 是否只输出带占位符的SQL及参数个数, 不输出参数值
+	SingleLineSQL             bool            // console编码下SQL是否与耗时输出在同一行
+	RowsKind                  bool            // 是否按语句类型区分rows_returned与rows_affected
 	Context                   context.Context
+	stats                     *gormStats   // SQL执行汇总计数, 未开启时为nil
+	metrics                   GormMetrics  // 指标推送, 未设置时为nil
+	limiter                   *gormLimiter // SQL错误限流, 未开启时为nil
 }
@@ -1,12 +1,20 @@
 package hlog
 
 import (
+	"errors"
+	"fmt"
 	"github.com/calmu/hgotool/logrotate" // 引入我们自己的轮转包
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"io"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
 	"sync"
+	"syscall"
+	"time"
 )
 
 // zapLogger 是基于zap的HLogger接口实现
@@ -14,6 +22,19 @@ type zapLogger struct {
 	logger       *zap.Logger
 	config       *LoggerConfig
 	rotateConfig *RotateConfig
+	rotateWriter *logrotate.RotateWriter // 轮转文件写入器, 仅轮转logger有效
+	asyncWriter  *logrotate.AsyncWriter  // 异步写入器, 仅开启异步写入时有效
+	stopCh       chan struct{}           // 停止丢弃统计协程
+	routes       *routeWriters           // 按字段路由的文件, 仅设置RouteKey时有效
+	closers      []io.Closer             // 输出路径打开的文件和网络连接, 仅NewZapLogger创建的logger有效
+	level        zap.AtomicLevel         // 可动态调整的日志级别
+	callerSkip   int                     // 配置的额外调用栈跳过层数
+
+	levelMu     sync.Mutex    // 保护临时级别调整
+	revertTimer *time.Timer   // 临时级别的恢复定时器
+	revertLevel zapcore.Level // 临时级别到期后恢复的级别
+
+	root *zapLogger // With派生logger的根logger, 级别调整和资源释放由根logger负责
 }
 
 // Warn 实现Warn方法
@@ -41,9 +62,163 @@ func (zl *zapLogger) Fatal(msg string, fields ...zap.Field) {
 	zl.logger.Fatal(msg, fields...)
 }
 
+// Sync 刷新缓冲的日志
+func (zl *zapLogger) Sync() error {
+	return zl.logger.Sync()
+}
+
+// With 返回附带fields的子logger，原logger不受影响
+//
+// 子logger与原logger共享输出和日志级别；子logger的Close只刷新缓冲，不释放共享的文件等资源，资源由原logger的Close释放
+func (zl *zapLogger) With(fields ...zap.Field) HLogger {
+	return zl.derive(zl.logger.With(fields...))
+}
+
+// Named 返回名称追加了name的子logger，名称输出到EncoderConfig.NameKey字段，多次调用以"."连接
+//
+// 子logger与原logger共享资源的方式同With
+func (zl *zapLogger) Named(name string) HLogger {
+	return zl.derive(zl.logger.Named(name))
+}
+
+// derive 基于logger创建与zl共享输出和级别的子logger
+func (zl *zapLogger) derive(logger *zap.Logger) *zapLogger {
+	root := zl.root
+	if root == nil {
+		root = zl
+	}
+	return &zapLogger{
+		logger:       logger,
+		config:       zl.config,
+		rotateConfig: zl.rotateConfig,
+		rotateWriter: zl.rotateWriter,
+		asyncWriter:  zl.asyncWriter,
+		routes:       zl.routes,
+		level:        zl.level,
+		callerSkip:   zl.callerSkip,
+		root:         root,
+	}
+}
+
 // Close 关闭logger，释放资源
+//
+// 轮转logger会关闭文件、停止缓冲刷新协程并等待进行中的压缩完成; 派生的子logger只执行Sync
 func (zl *zapLogger) Close() error {
-	return zl.logger.Sync()
+	if zl.root != nil {
+		return zl.logger.Sync()
+	}
+	if zl.stopCh != nil {
+		close(zl.stopCh)
+		zl.stopCh = nil
+	}
+	err := zl.logger.Sync()
+	var closeErr error
+	switch {
+	case len(zl.closers) > 0:
+		closeErr = closeAll(zl.closers)
+		zl.closers = nil
+	case zl.routes != nil:
+		closeErr = zl.routes.close()
+	case zl.asyncWriter != nil:
+		// 异步写入器关闭时同时关闭其下的轮转写入器
+		closeErr = zl.asyncWriter.Close()
+	case zl.rotateWriter != nil:
+		closeErr = zl.rotateWriter.Close()
+	}
+	return errors.Join(err, closeErr)
+}
+
+// SetLevel 立即将日志级别调整为level，并取消SetLevelFor尚未执行的恢复计划
+func (zl *zapLogger) SetLevel(level string) error {
+	if zl.root != nil {
+		return zl.root.SetLevel(level)
+	}
+	lvl, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	zl.levelMu.Lock()
+	defer zl.levelMu.Unlock()
+
+	if zl.revertTimer != nil {
+		zl.revertTimer.Stop()
+		zl.revertTimer = nil
+	}
+	zl.level.SetLevel(lvl)
+	return nil
+}
+
+// Level 返回当前日志级别
+func (zl *zapLogger) Level() string {
+	return zl.level.Level().String()
+}
+
+// SetLevelFor 临时将日志级别调整为level，d之后自动恢复为调整前的级别
+//
+// 再次调用会取消之前的恢复计划，并以最初的级别作为恢复目标；无效的level会被忽略
+func (zl *zapLogger) SetLevelFor(level string, d time.Duration) {
+	if zl.root != nil {
+		zl.root.SetLevelFor(level, d)
+		return
+	}
+	lvl, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return
+	}
+
+	zl.levelMu.Lock()
+	defer zl.levelMu.Unlock()
+
+	if zl.revertTimer == nil {
+		zl.revertLevel = zl.level.Level()
+	} else {
+		zl.revertTimer.Stop()
+	}
+	zl.level.SetLevel(lvl)
+
+	var timer *time.Timer
+	timer = time.AfterFunc(d, func() {
+		zl.levelMu.Lock()
+		defer zl.levelMu.Unlock()
+		// 已被新的调用取代
+		if zl.revertTimer != timer {
+			return
+		}
+		zl.level.SetLevel(zl.revertLevel)
+		zl.revertTimer = nil
+	})
+	zl.revertTimer = timer
+}
+
+// DroppedCount 返回异步写入队列满时丢弃的日志条数，未开启异步写入时为0
+func (zl *zapLogger) DroppedCount() int64 {
+	if zl.asyncWriter == nil {
+		return 0
+	}
+	return zl.asyncWriter.DroppedCount()
+}
+
+// reportDropped 周期输出异步写入丢弃的日志条数
+func (zl *zapLogger) reportDropped(stopCh chan struct{}) {
+	ticker := time.NewTicker(asyncDropReportInterval)
+	defer ticker.Stop()
+	var last int64
+	for {
+		select {
+		case <-ticker.C:
+			total := zl.asyncWriter.DroppedCount()
+			if total > last {
+				zl.logger.Warn("async log entries dropped",
+					zap.Int64("dropped", total-last),
+					zap.Int64("total_dropped", total),
+				)
+				last = total
+			}
+		case <-stopCh:
+			return
+		}
+	}
 }
 
 // EncoderConfig 编码器配置结构
@@ -65,54 +240,197 @@ type EncoderConfig struct {
 	HideLevel  bool // 是否隐藏日志级别
 	HideTime   bool // 是否隐藏时间戳
 	HideName   bool // 是否隐藏名称字段
+	// ForceColor 为true时所有输出都保留EncodeLevel的颜色; 默认只有终端的标准输出和标准错误保留颜色, 文件等输出去除颜色转义序列
+	ForceColor bool
+	// RedactKeys 需要脱敏的字段名(不区分大小写), 匹配的字段值输出为 ***; 对象字段和字符串键的map中的键同样生效
+	RedactKeys []string
+	// NonFiniteFloat NaN和Inf浮点数的输出方式: "null"输出为null, 其他非空值作为字符串输出;
+	// 默认保持zap的行为, 对象和map中的NaN会导致整个字段序列化失败
+	NonFiniteFloat string
 }
 
 // LoggerConfig 日志配置结构
 type LoggerConfig struct {
 	Level         string         // 日志级别: debug, info, warn, error, dpanic, panic, fatal
-	OutputPath    []string       // 输出路径
+	OutputPath    []string       // 输出路径: stdout、stderr、文件路径, 或 tcp://host:port、udp://host:port 网络地址
 	Encoder       string         // 编码器: json, console
 	EncoderConfig *EncoderConfig // 编码器详细配置
+	// RedactPatterns 脱敏正则, 编码后整行日志中匹配的内容替换为 ***; 每行都会执行正则, 有一定开销
+	RedactPatterns []string
+	// FallbackToStdout 输出路径无法创建或打开时改用标准输出(旧行为); 默认返回错误, 避免日志静默丢失
+	FallbackToStdout bool
+	// CallerSkip 额外跳过的调用栈层数, 在自定义函数中封装HLogger时设置为封装的层数, 使caller指向真正的调用处
+	CallerSkip int
+	// Sampling 日志采样配置, 为nil时不采样
+	Sampling *SamplingConfig
+	// LevelSinks 按级别范围写入的额外输出, 如info写入一个文件、warn及以上写入另一个文件; 仍受Level限制
+	LevelSinks []LevelSink
+	// Strict 为true时NewZapLogger先调用Validate, 配置无效时返回错误而不是按默认值处理
+	Strict bool
+	// InitialFields 每条日志都附带的静态字段, 如 service、env; 值按类型转换为对应的zap字段
+	InitialFields map[string]interface{}
+}
+
+// LevelSink 按级别范围输出的配置
+type LevelSink struct {
+	MinLevel   string   // 最低级别(含), 为空表示不限制
+	MaxLevel   string   // 最高级别(含), 为空表示不限制
+	OutputPath []string // 输出路径, 同LoggerConfig.OutputPath
+}
+
+// enabler 返回在级别范围内且不低于level的LevelEnabler
+func (s LevelSink) enabler(level zap.AtomicLevel) (zapcore.LevelEnabler, error) {
+	minLevel, maxLevel := zapcore.DebugLevel, zapcore.FatalLevel
+	var err error
+	if s.MinLevel != "" {
+		if minLevel, err = zapcore.ParseLevel(s.MinLevel); err != nil {
+			return nil, err
+		}
+	}
+	if s.MaxLevel != "" {
+		if maxLevel, err = zapcore.ParseLevel(s.MaxLevel); err != nil {
+			return nil, err
+		}
+	}
+	return zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+		return level.Enabled(l) && l >= minLevel && l <= maxLevel
+	}), nil
+}
+
+// SamplingConfig 日志采样配置, 相同级别和消息的日志在每个Tick内先输出Initial条, 之后每Thereafter条输出1条
+type SamplingConfig struct {
+	Initial    int           // 每个Tick内不采样直接输出的条数
+	Thereafter int           // 超过Initial后每多少条输出1条, 0表示超过后全部丢弃
+	Tick       time.Duration // 计数周期, 默认1秒
+}
+
+// wrapCore 为core添加采样, c为nil时原样返回
+func (c *SamplingConfig) wrapCore(core zapcore.Core) zapcore.Core {
+	if c == nil {
+		return core
+	}
+	tick := c.Tick
+	if tick <= 0 {
+		tick = time.Second
+	}
+	return zapcore.NewSamplerWithOptions(core, tick, c.Initial, c.Thereafter)
 }
 
 // RotateConfig 定义轮转配置
 type RotateConfig struct {
 	// 时间轮转配置
-	TimeRotation string // "daily", "hourly", "minutely"
+	TimeRotation string // "monthly", "weekly", "daily", "hourly", "minutely", 为空时只按大小轮转
+	RotateStyle  string // 文件命名方式: timestamp(默认), rename, 含义同logrotate.RotateConfig.RotateStyle
 
 	// 大小轮转配置
 	MaxSize    int64 // MB
 	MaxBackups int   // 最大备份文件数
 	MaxAge     int   // 保留天数
 	Compress   bool  // 是否压缩
+	// MaxTotalSize 所有轮转文件的总大小上限(MB), 含义同logrotate.RotateConfig.MaxTotalSize
+	MaxTotalSize int64
+	// CompressLevel gzip压缩级别, 含义同logrotate.RotateConfig.CompressLevel
+	CompressLevel int
+
+	// 异步写入配置, 仅作用于轮转文件输出
+	AsyncQueueSize int                      // 异步写入队列长度, 0表示同步写入
+	AsyncOverflow  logrotate.OverflowPolicy // 队列满时的处理策略, 默认阻塞
+
+	// 缓冲写入配置, 含义同logrotate.RotateConfig.BufferSize/FlushInterval, 缓冲数据在Sync/Close时全部刷新
+	BufferSize    int
+	FlushInterval time.Duration
+
+	// 按字段路由配置, 设置RouteKey后文件输出按该字段的值写入 <Filename前缀>_<值><扩展名>, 不含该字段的日志写入Filename
+	RouteKey     string // 路由字段名, 为空表示不路由; 路由模式下不使用异步写入
+	RouteMaxOpen int    // 路由时同时打开的最大文件数, 超出时关闭最久未使用的文件, 默认64
 
 	// 基础配置
 	Filename      string         // 基础文件名
 	Level         string         // 日志级别
 	Encoder       string         // 编码器: json, console
 	EncoderConfig *EncoderConfig // 编码器详细配置
-	OutputType    string         // 输出类型: file, stdout, 或两者
+	OutputType    string         // 输出类型: file, stdout, both(两者), console-split(文件, 且warn以下写入stdout、warn及以上写入stderr)
+	// RedactPatterns 脱敏正则, 编码后整行日志中匹配的内容替换为 ***; 每行都会执行正则, 有一定开销
+	RedactPatterns []string
+	// CallerSkip 额外跳过的调用栈层数, 含义同LoggerConfig.CallerSkip
+	CallerSkip int
+	// Sampling 日志采样配置, 为nil时不采样
+	Sampling *SamplingConfig
+	// Strict 为true时NewRotatingLogger先调用Validate, 配置无效时返回错误
+	Strict bool
+	// InitialFields 每条日志都附带的静态字段, 含义同LoggerConfig.InitialFields
+	InitialFields map[string]interface{}
+	// FileMode 新建日志文件的权限, 0时使用0644; DirMode 新建日志目录的权限, 0时使用0755
+	FileMode os.FileMode
+	DirMode  os.FileMode
+	// FallbackThreshold 大于0时, 轮转文件连续写入失败(如磁盘已满)达到该次数后改写标准错误, 写入成功后恢复写入文件
+	FallbackThreshold int
 }
 
+// Merge 以base为基础合并override中的非零值字段，返回新的配置
+//
+// 由于按零值判断是否覆盖，override无法将字段显式设置为零值(如 false、0、空字符串)，
+// 需要零值时请直接修改合并后的结果
+func (base RotateConfig) Merge(override RotateConfig) RotateConfig {
+	merged := base
+	mv := reflect.ValueOf(&merged).Elem()
+	ov := reflect.ValueOf(override)
+	for i := 0; i < ov.NumField(); i++ {
+		if f := ov.Field(i); !f.IsZero() {
+			mv.Field(i).Set(f)
+		}
+	}
+	return merged
+}
+
+// asyncDropReportInterval 异步写入丢弃统计的输出间隔
+const asyncDropReportInterval = time.Minute
+
 // 全局logger映射，用于存储不同类型的logger
 var (
 	GlobalLoggers = make(map[string]HLogger)
 	loggersMutex  sync.RWMutex
 )
 
-// GetLogger 获取指定类型的全局logger实例
-func GetLogger(loggerType string) HLogger {
+// defaultLoggerType 可通过SetLogger替换默认logger的类型名
+const defaultLoggerType = "default"
+
+// 未注册类型时复用的默认logger
+var (
+	defaultLoggerOnce sync.Once
+	defaultLogger     HLogger
+)
+
+// GetLoggerOK 获取指定类型的全局logger实例，不存在时返回nil和false，便于发现类型名拼写错误
+func GetLoggerOK(loggerType string) (HLogger, bool) {
 	loggersMutex.RLock()
 	defer loggersMutex.RUnlock()
 
 	logger, exists := GlobalLoggers[loggerType]
-	if !exists {
-		// 如果不存在，则返回默认logger
-		defaultLogger := createDefaultLogger()
-		return defaultLogger
+	return logger, exists
+}
+
+// GetLoggerOrDefault 获取指定类型的全局logger实例，不存在时返回默认logger
+//
+// 默认logger优先使用SetLogger("default", ...)注册的logger，否则为首次使用时创建并复用的标准输出logger
+func GetLoggerOrDefault(loggerType string) HLogger {
+	if logger, ok := GetLoggerOK(loggerType); ok {
+		return logger
 	}
+	if logger, ok := GetLoggerOK(defaultLoggerType); ok {
+		return logger
+	}
+	defaultLoggerOnce.Do(func() {
+		defaultLogger = createDefaultLogger()
+	})
+	return defaultLogger
+}
 
-	return logger
+// GetLogger 获取指定类型的全局logger实例
+//
+// 类型不存在时返回默认logger，行为同GetLoggerOrDefault；需要区分类型是否存在时请使用GetLoggerOK
+func GetLogger(loggerType string) HLogger {
+	return GetLoggerOrDefault(loggerType)
 }
 
 // SetLogger 设置指定类型的全局logger
@@ -123,6 +441,35 @@ func SetLogger(loggerType string, logger HLogger) {
 	GlobalLoggers[loggerType] = logger
 }
 
+// CloseAll 关闭GlobalLoggers中的所有logger并清空映射，用于程序退出前刷新缓冲的日志
+//
+// 返回所有关闭失败的错误；同一个logger注册在多个类型下时只关闭一次
+func CloseAll() error {
+	loggersMutex.Lock()
+	defer loggersMutex.Unlock()
+
+	var errs []error
+	closed := make(map[HLogger]bool)
+	for loggerType, logger := range GlobalLoggers {
+		if logger == nil {
+			continue
+		}
+		canCompare := reflect.TypeOf(logger).Comparable()
+		if canCompare && closed[logger] {
+			continue
+		}
+		// 标准输出为终端或管道时Sync会返回EINVAL/ENOTTY, 不视为错误
+		if err := logger.Close(); err != nil && !errors.Is(err, syscall.EINVAL) && !errors.Is(err, syscall.ENOTTY) {
+			errs = append(errs, fmt.Errorf("close logger %q: %w", loggerType, err))
+		}
+		if canCompare {
+			closed[logger] = true
+		}
+	}
+	clear(GlobalLoggers)
+	return errors.Join(errs...)
+}
+
 // createDefaultLogger 创建默认logger
 func createDefaultLogger() HLogger {
 	config := LoggerConfig{
@@ -138,156 +485,351 @@ func createDefaultLogger() HLogger {
 
 // NewZapLogger 根据普通配置创建新的zap logger
 func NewZapLogger(config LoggerConfig) (HLogger, error) {
-	var level zapcore.Level
-	switch config.Level {
+	if config.Strict {
+		if err := config.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	level := zap.NewAtomicLevelAt(parseLevel(config.Level))
+
+	redactPatterns, err := compileRedactPatterns(config.RedactPatterns)
+	if err != nil {
+		return nil, err
+	}
+	encoders := newEncoders(config.Encoder, config.EncoderConfig, redactPatterns)
+
+	outputs, closers, err := getOutputs(config.OutputPath, config.FallbackToStdout)
+	if err != nil {
+		return nil, err
+	}
+	// 按字段名脱敏包装在每个输出的core上, 保证Tee中各core的级别检查
+	redactKeys := redactKeysOf(config.EncoderConfig)
+	core := encoders.newCore(outputs, level, redactKeys)
+
+	if len(config.LevelSinks) > 0 {
+		cores := []zapcore.Core{core}
+		for _, sink := range config.LevelSinks {
+			enabler, err := sink.enabler(level)
+			if err != nil {
+				closeAll(closers)
+				return nil, err
+			}
+			sinkOutputs, sinkClosers, err := getOutputs(sink.OutputPath, config.FallbackToStdout)
+			if err != nil {
+				closeAll(closers)
+				return nil, err
+			}
+			closers = append(closers, sinkClosers...)
+			cores = append(cores, encoders.newCore(sinkOutputs, enabler, redactKeys))
+		}
+		core = zapcore.NewTee(cores...)
+	}
+	core = newHookCore(core, config.EncoderConfig)
+	core = config.Sampling.wrapCore(core)
+
+	loggerInstance := zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1+config.CallerSkip), initialFields(config.InitialFields))
+
+	return &zapLogger{
+		logger:     loggerInstance,
+		config:     &config,
+		closers:    closers,
+		level:      level,
+		callerSkip: config.CallerSkip,
+	}, nil
+}
+
+// initialFields 将静态字段按键名排序后转换为zap选项, 保证输出顺序稳定
+func initialFields(fields map[string]interface{}) zap.Option {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	zapFields := make([]zap.Field, 0, len(keys))
+	for _, key := range keys {
+		zapFields = append(zapFields, zap.Any(key, fields[key]))
+	}
+	return zap.Fields(zapFields...)
+}
+
+// parseLevel 解析日志级别，未知级别按info处理
+func parseLevel(level string) zapcore.Level {
+	switch level {
 	case "debug":
-		level = zapcore.DebugLevel
+		return zapcore.DebugLevel
 	case "info":
-		level = zapcore.InfoLevel
+		return zapcore.InfoLevel
 	case "warn":
-		level = zapcore.WarnLevel
+		return zapcore.WarnLevel
 	case "error":
-		level = zapcore.ErrorLevel
+		return zapcore.ErrorLevel
 	case "dpanic":
-		level = zapcore.DPanicLevel
+		return zapcore.DPanicLevel
 	case "panic":
-		level = zapcore.PanicLevel
+		return zapcore.PanicLevel
 	case "fatal":
-		level = zapcore.FatalLevel
+		return zapcore.FatalLevel
 	default:
-		level = zapcore.InfoLevel
+		return zapcore.InfoLevel
 	}
+}
 
-	var encoder zapcore.Encoder
-	if config.Encoder == "json" {
-		encoderConfig := getEncoderConfig(config.EncoderConfig, "json")
-		encoder = zapcore.NewJSONEncoder(encoderConfig)
-	} else {
-		encoderConfig := getEncoderConfig(config.EncoderConfig, "console")
-		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+// isFilePath 输出路径是否为文件, 与getOutputs的判断一致: 标准输出、标准错误和网络地址不是文件
+func isFilePath(path string) bool {
+	if path == "stdout" || path == "stderr" {
+		return false
 	}
-
-	writeSyncer := zapcore.NewMultiWriteSyncer(getWriteSyncers(config.OutputPath)...)
-	core := zapcore.NewCore(encoder, writeSyncer, level)
-
-	loggerInstance := zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1))
-
-	return &zapLogger{
-		logger: loggerInstance,
-		config: &config,
-	}, nil
+	_, _, ok := parseNetPath(path)
+	return !ok
 }
 
-// getWriteSyncers 根据路径创建WriteSyncer
-func getWriteSyncers(paths []string) []zapcore.WriteSyncer {
-	var writeSyncers []zapcore.WriteSyncer
+// getOutputs 根据路径创建输出, 同时返回需要在关闭logger时释放的文件和网络连接
+// fallback为true时, 无法创建目录或打开文件的路径改用标准输出; 否则关闭已打开的输出并返回所有失败路径的错误
+func getOutputs(paths []string, fallback bool) ([]output, []io.Closer, error) {
+	var outputs []output
+	var closers []io.Closer
+	var errs []error
 	for _, path := range paths {
 		if path == "stdout" {
-			writeSyncers = append(writeSyncers, zapcore.AddSync(zapcore.Lock(os.Stdout)))
-		} else {
-			// 确保目录存在
-			dir := filepath.Dir(path)
-			if err := os.MkdirAll(dir, 0755); err != nil {
-				// 如果创建目录失败，仍然使用标准输出
-				writeSyncers = append(writeSyncers, zapcore.AddSync(zapcore.Lock(os.Stdout)))
-				continue
-			}
+			outputs = append(outputs, stdOutput(os.Stdout))
+			continue
+		}
+		if path == "stderr" {
+			outputs = append(outputs, stdOutput(os.Stderr))
+			continue
+		}
+		if network, addr, ok := parseNetPath(path); ok {
+			sink := newNetWriteSyncer(network, addr)
+			closers = append(closers, sink)
+			outputs = append(outputs, output{ws: sink})
+			continue
+		}
 
-			file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
-			if err != nil {
-				// 如果打开文件失败，仍然使用标准输出
-				writeSyncers = append(writeSyncers, zapcore.AddSync(zapcore.Lock(os.Stdout)))
+		// 确保目录存在
+		dir := filepath.Dir(path)
+		err := os.MkdirAll(dir, 0755)
+		var file *os.File
+		if err == nil {
+			file, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		}
+		if err != nil {
+			if fallback {
+				// 回退模式下仍然使用标准输出
+				outputs = append(outputs, stdOutput(os.Stdout))
 			} else {
-				writeSyncers = append(writeSyncers, zapcore.AddSync(file))
+				errs = append(errs, fmt.Errorf("open output path %q: %w", path, err))
 			}
+			continue
+		}
+		closers = append(closers, file)
+		outputs = append(outputs, output{ws: zapcore.AddSync(file)})
+	}
+
+	if len(errs) > 0 {
+		closeAll(closers)
+		return nil, nil, errors.Join(errs...)
+	}
+	return outputs, closers, nil
+}
+
+// closeAll 依次关闭所有closer, 返回第一个错误
+func closeAll(closers []io.Closer) error {
+	var firstErr error
+	for _, c := range closers {
+		if err := c.Close(); err != nil && firstErr == nil {
+			firstErr = err
 		}
 	}
-	return writeSyncers
+	return firstErr
 }
 
 // NewRotatingLogger 创建支持轮转的日志记录器
 func NewRotatingLogger(rotateConfig RotateConfig) (HLogger, error) {
-	var level zapcore.Level
-	switch rotateConfig.Level {
-	case "debug":
-		level = zapcore.DebugLevel
-	case "info":
-		level = zapcore.InfoLevel
-	case "warn":
-		level = zapcore.WarnLevel
-	case "error":
-		level = zapcore.ErrorLevel
-	case "dpanic":
-		level = zapcore.DPanicLevel
-	case "panic":
-		level = zapcore.PanicLevel
-	case "fatal":
-		level = zapcore.FatalLevel
-	default:
-		level = zapcore.InfoLevel
+	if rotateConfig.Strict {
+		if err := rotateConfig.Validate(); err != nil {
+			return nil, err
+		}
 	}
+	level := zap.NewAtomicLevelAt(parseLevel(rotateConfig.Level))
 
-	var encoder zapcore.Encoder
-	if rotateConfig.Encoder == "json" {
-		encoderConfig := getEncoderConfig(rotateConfig.EncoderConfig, "json")
-		encoder = zapcore.NewJSONEncoder(encoderConfig)
-	} else {
-		encoderConfig := getEncoderConfig(rotateConfig.EncoderConfig, "console")
-		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+	redactPatterns, err := compileRedactPatterns(rotateConfig.RedactPatterns)
+	if err != nil {
+		return nil, err
 	}
+	encoders := newEncoders(rotateConfig.Encoder, rotateConfig.EncoderConfig, redactPatterns)
 
-	var writeSyncers []zapcore.WriteSyncer
+	var outputs []output
+	var rotatingWriter *logrotate.RotateWriter
+	var asyncWriter *logrotate.AsyncWriter
+	var routes *routeWriters
 
 	// 添加标准输出
 	if rotateConfig.OutputType == "stdout" || rotateConfig.OutputType == "both" {
-		writeSyncers = append(writeSyncers, zapcore.AddSync(zapcore.Lock(os.Stdout)))
+		outputs = append(outputs, stdOutput(os.Stdout))
 	}
 
 	// 添加轮转文件输出
-	if rotateConfig.OutputType == "file" || rotateConfig.OutputType == "both" {
+	if rotateConfig.writesFile() {
 		// 确保目录存在 - logrotate包内部会处理目录创建
 		rotatingConfig := logrotate.RotateConfig{
-			TimeRotation: rotateConfig.TimeRotation,
-			MaxSize:      rotateConfig.MaxSize,
-			MaxBackups:   rotateConfig.MaxBackups,
-			MaxAge:       rotateConfig.MaxAge,
-			Compress:     rotateConfig.Compress,
-			Filename:     rotateConfig.Filename,
+			TimeRotation:      rotateConfig.TimeRotation,
+			RotateStyle:       rotateConfig.RotateStyle,
+			MaxSize:           rotateConfig.MaxSize,
+			MaxBackups:        rotateConfig.MaxBackups,
+			MaxAge:            rotateConfig.MaxAge,
+			MaxTotalSize:      rotateConfig.MaxTotalSize,
+			Compress:          rotateConfig.Compress,
+			CompressLevel:     rotateConfig.CompressLevel,
+			Filename:          rotateConfig.Filename,
+			FileMode:          rotateConfig.FileMode,
+			DirMode:           rotateConfig.DirMode,
+			FallbackThreshold: rotateConfig.FallbackThreshold,
+			BufferSize:        rotateConfig.BufferSize,
+			FlushInterval:     rotateConfig.FlushInterval,
 		}
 
-		rotatingWriter, err := logrotate.NewRotateWriter(rotatingConfig)
-		if err != nil {
-			return nil, err
+		if rotateConfig.RouteKey != "" {
+			routes, err = newRouteWriters(rotatingConfig, rotateConfig.RouteMaxOpen)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			rotatingWriter, err = logrotate.NewRotateWriter(rotatingConfig)
+			if err != nil {
+				return nil, err
+			}
+
+			if rotateConfig.AsyncQueueSize > 0 {
+				asyncWriter = logrotate.NewAsyncWriter(rotatingWriter, rotateConfig.AsyncQueueSize, rotateConfig.AsyncOverflow)
+				outputs = append(outputs, output{ws: zapcore.AddSync(asyncWriter)})
+			} else {
+				outputs = append(outputs, output{ws: zapcore.AddSync(rotatingWriter)})
+			}
 		}
+	}
 
-		writeSyncers = append(writeSyncers, zapcore.AddSync(rotatingWriter))
+	// 按字段名脱敏包装在每个输出的core上, 保证Tee中各core的级别检查
+	redactKeys := redactKeysOf(rotateConfig.EncoderConfig)
+	var core zapcore.Core
+	if routes != nil {
+		core = newRedactKeyCore(newRouteCore(encoders.pick(false).Clone(), level, rotateConfig.RouteKey, routes), redactKeys)
+		if len(outputs) > 0 {
+			core = zapcore.NewTee(encoders.newCore(outputs, level, redactKeys), core)
+		}
+	} else {
+		core = encoders.newCore(outputs, level, redactKeys)
+	}
+	if rotateConfig.OutputType == "console-split" {
+		core = zapcore.NewTee(core, consoleSplitCore(encoders, level, redactKeys))
 	}
 
-	writeSyncer := zapcore.NewMultiWriteSyncer(writeSyncers...)
-	core := zapcore.NewCore(encoder, writeSyncer, level)
+	core = newHookCore(core, rotateConfig.EncoderConfig)
+	core = rotateConfig.Sampling.wrapCore(core)
 
-	loggerInstance := zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1))
+	loggerInstance := zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1+rotateConfig.CallerSkip), initialFields(rotateConfig.InitialFields))
 
-	return &zapLogger{
+	zl := &zapLogger{
 		logger:       loggerInstance,
 		rotateConfig: &rotateConfig,
-	}, nil
+		rotateWriter: rotatingWriter,
+		asyncWriter:  asyncWriter,
+		routes:       routes,
+		level:        level,
+		callerSkip:   rotateConfig.CallerSkip,
+	}
+	if asyncWriter != nil {
+		zl.stopCh = make(chan struct{})
+		go zl.reportDropped(zl.stopCh)
+	}
+	return zl, nil
 }
 
-// InitLogger 初始化指定类型的logger
+// writesFile 输出类型是否包含轮转文件
+func (c RotateConfig) writesFile() bool {
+	return c.OutputType == "file" || c.OutputType == "both" || c.OutputType == "console-split"
+}
+
+// consoleSplitCore 返回按级别分流的core, warn以下写入标准输出, warn及以上写入标准错误, 两者分别按redactKeys脱敏
+func consoleSplitCore(encoders encoderPair, level zap.AtomicLevel, redactKeys []string) zapcore.Core {
+	low := zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+		return level.Enabled(l) && l < zapcore.WarnLevel
+	})
+	high := zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+		return level.Enabled(l) && l >= zapcore.WarnLevel
+	})
+	return zapcore.NewTee(
+		encoders.newCore([]output{stdOutput(os.Stdout)}, low, redactKeys),
+		encoders.newCore([]output{stdOutput(os.Stderr)}, high, redactKeys),
+	)
+}
+
+// InitLogger 初始化指定类型的logger, 创建失败时通过默认logger输出错误且不设置该类型; 需要处理错误时请使用InitLoggerE
 func InitLogger(loggerType string, config LoggerConfig) {
+	if err := InitLoggerE(loggerType, config); err != nil {
+		logInitError(loggerType, err)
+	}
+}
+
+// InitLoggerE 初始化指定类型的logger, 创建失败时返回错误且不设置该类型
+func InitLoggerE(loggerType string, config LoggerConfig) error {
 	logger, err := NewZapLogger(config)
-	if err == nil {
-		SetLogger(loggerType, logger)
+	if err != nil {
+		return err
 	}
+	SetLogger(loggerType, logger)
+	return nil
 }
 
-// InitRotatingLogger 初始化指定类型的轮转logger
+// InitRotatingLogger 初始化指定类型的轮转logger, 创建失败时通过默认logger输出错误且不设置该类型; 需要处理错误时请使用InitRotatingLoggerE
 func InitRotatingLogger(loggerType string, rotateConfig RotateConfig) {
+	if err := InitRotatingLoggerE(loggerType, rotateConfig); err != nil {
+		logInitError(loggerType, err)
+	}
+}
+
+// InitRotatingLoggerE 初始化指定类型的轮转logger, 创建失败时返回错误且不设置该类型
+func InitRotatingLoggerE(loggerType string, rotateConfig RotateConfig) error {
 	logger, err := NewRotatingLogger(rotateConfig)
-	if err == nil {
-		SetLogger(loggerType, logger)
+	if err != nil {
+		return err
+	}
+	SetLogger(loggerType, logger)
+	return nil
+}
+
+// logInitError 通过默认logger输出logger初始化失败的错误
+func logInitError(loggerType string, err error) {
+	GetLoggerOrDefault(defaultLoggerType).Error("init logger failed", zap.String("logger_type", loggerType), zap.Error(err))
+}
+
+// newEncoders 创建编码器; 级别编码带颜色且未设置ForceColor时, 额外创建不带颜色的编码器供非终端输出使用
+func newEncoders(encoderType string, config *EncoderConfig, redactPatterns []*regexp.Regexp) encoderPair {
+	encoders := encoderPair{color: newEncoder(encoderType, config, redactPatterns, false)}
+	if stripColor(config) {
+		encoders.plain = newEncoder(encoderType, config, redactPatterns, true)
 	}
+	return encoders
+}
+
+// newEncoder 按类型创建编码器并包装NaN/Inf替换和正则脱敏, plain为true时级别编码不带颜色
+func newEncoder(encoderType string, config *EncoderConfig, redactPatterns []*regexp.Regexp, plain bool) zapcore.Encoder {
+	var encoder zapcore.Encoder
+	if encoderType == "json" {
+		encoderConfig := getEncoderConfig(config, "json")
+		if plain {
+			encoderConfig.EncodeLevel = plainLevelEncoder(config.EncodeLevel)
+		}
+		encoder = zapcore.NewJSONEncoder(encoderConfig)
+	} else {
+		encoderConfig := getEncoderConfig(config, "console")
+		if plain {
+			encoderConfig.EncodeLevel = plainLevelEncoder(config.EncodeLevel)
+		}
+		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+	}
+	encoder = newFloatEncoder(encoder, config)
+	return newRedactEncoder(encoder, redactPatterns)
 }
 
 // getEncoderConfig 根据配置获取编码器配置
@@ -402,6 +944,10 @@ func getEncoderConfig(config *EncoderConfig, encoderType string) zapcore.Encoder
 		}
 	}
 
+	if n := newNonFiniteFloat(config); n != nil {
+		encoderConfig.NewReflectedEncoder = n.newFloatReflectedEncoder
+	}
+
 	// 设置调用者编码格式
 	if config.EncodeCaller != "" {
 		switch config.EncodeCaller {
@@ -1,9 +1,14 @@
 package hlog
 
 import (
+	"fmt"
+	"github.com/calmu/hgotool/logrotate"
 	"go.uber.org/zap"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 	"time"
 )
@@ -118,6 +123,47 @@ func TestConfigChangeForSpecificLogger(t *testing.T) {
 	}
 }
 
+func TestGetLoggerOK(t *testing.T) {
+	InitLogger("lookup_present", LoggerConfig{
+		Level:      "info",
+		OutputPath: []string{"stdout"},
+		Encoder:    "console",
+	})
+
+	if logger, ok := GetLoggerOK("lookup_present"); !ok || logger == nil {
+		t.Errorf("expected registered logger, got %v, %v", logger, ok)
+	}
+	if logger, ok := GetLoggerOK("lookup_typo"); ok || logger != nil {
+		t.Errorf("expected missing logger, got %v, %v", logger, ok)
+	}
+	if logger := GetLoggerOrDefault("lookup_typo"); logger == nil {
+		t.Error("expected default logger for missing type")
+	}
+}
+
+func TestDefaultLoggerCached(t *testing.T) {
+	first := GetLogger("nope")
+	second := GetLogger("nope")
+	if first == nil || first != second {
+		t.Errorf("expected the same default logger instance, got %p and %p", first, second)
+	}
+
+	// 注册"default"后替换默认logger
+	replacement, err := NewZapLogger(LoggerConfig{Level: "info", OutputPath: []string{"stdout"}, Encoder: "console"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	SetLogger("default", replacement)
+	defer func() {
+		loggersMutex.Lock()
+		delete(GlobalLoggers, "default")
+		loggersMutex.Unlock()
+	}()
+	if got := GetLogger("nope"); got != replacement {
+		t.Error("expected the registered default logger to be returned")
+	}
+}
+
 func TestGlobalLoggersMap(t *testing.T) {
 	// 测试GlobalLoggers映射是否可以被外部访问
 	config := LoggerConfig{
@@ -203,7 +249,7 @@ func TestSizeRotationLogger(t *testing.T) {
 			Encoder:      "json",
 			OutputType:   "file",
 			Filename:     "./log/sizerotated/app.log",
-			TimeRotation: "", // 不按时间轮转 - 但logrotate包仍会添加时间戳
+			TimeRotation: "", // 不按时间轮转, 超过大小后按序号滚动
 			MaxSize:      1,  // 1MB后轮转
 			MaxBackups:   2,  // 保留2个备份
 			MaxAge:       5,  // 保留5天
@@ -222,9 +268,8 @@ func TestSizeRotationLogger(t *testing.T) {
 	// 等待确保日志写入文件
 	time.Sleep(100 * time.Millisecond)
 
-	// 验证日志文件是否已创建 - 对于大小轮转，logrotate包仍会添加时间戳
-	today := time.Now().Format("2006-01-02")
-	logFile := filepath.Join("./log/sizerotated", "app_"+today+".log")
+	// 验证日志文件是否已创建 - 对于大小轮转，直接写入基础文件名
+	logFile := filepath.Join("./log/sizerotated", "app.log")
 
 	if _, err := os.Stat(logFile); os.IsNotExist(err) {
 		t.Errorf("Size-based log file was not created: %s", logFile)
@@ -319,6 +364,50 @@ func TestCloseMethod(t *testing.T) {
 	}
 }
 
+func TestCloseRotatingLogger(t *testing.T) {
+	dir := t.TempDir()
+	before := runtime.NumGoroutine()
+
+	logger, err := NewRotatingLogger(RotateConfig{
+		Filename:   filepath.Join(dir, "app.log"),
+		MaxSize:    1,
+		Compress:   true,
+		BufferSize: 4096,
+		Level:      "info",
+		Encoder:    "json",
+		OutputType: "file",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	// 写满1MB触发滚动, 备份文件在后台压缩
+	payload := strings.Repeat("x", 1024)
+	for i := 0; i < 1100; i++ {
+		logger.Info(payload)
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	// Close等待压缩完成
+	if _, err := os.Stat(filepath.Join(dir, "app.1.log.gz")); err != nil {
+		t.Errorf("expected compressed backup after Close: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "app.1.log")); !os.IsNotExist(err) {
+		t.Error("expected uncompressed backup to be removed")
+	}
+
+	// 缓冲刷新协程已退出
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Errorf("expected no leaked goroutines, got %d (before %d)", n, before)
+	}
+}
+
 func TestCustomEncoderConfig(t *testing.T) {
 	// 确保日志目录存在
 	os.MkdirAll("./log", 0755)
@@ -387,15 +476,14 @@ func TestCustomJSONEncoderConfig(t *testing.T) {
 	// 记录日志
 	logger.Info("Test message with custom JSON format", zap.String("test", "json_format"))
 
-	// 等待确保日志写入文件
-	time.Sleep(100 * time.Millisecond)
-
-	// 验证日志文件是否已创建
+	// 刷新后直接读取日志文件，无需等待
 	logFile := "./log/custom_json.log"
-	if _, err := os.Stat(logFile); os.IsNotExist(err) {
-		t.Errorf("Custom JSON log file was not created: %s", logFile)
-	} else {
-		t.Logf("Custom JSON log file created successfully: %s", logFile)
+	data, err := SyncAndRead(logger, logFile)
+	if err != nil {
+		t.Fatalf("Custom JSON log file was not created: %s", logFile)
+	}
+	if !ContainsField(data, "test", "json_format") || !ContainsField(data, "severity", "INFO") {
+		t.Errorf("Custom JSON log file missing expected fields: %s", data)
 	}
 
 	// 调用Close方法
@@ -403,3 +491,653 @@ func TestCustomJSONEncoderConfig(t *testing.T) {
 		closer.Close()
 	}
 }
+
+func TestAsyncRotatingLogger(t *testing.T) {
+	// 确保日志目录存在
+	os.MkdirAll("./log", 0755)
+	os.RemoveAll("./log/asyncrotated")
+
+	logger, err := NewRotatingLogger(RotateConfig{
+		Level:          "info",
+		Encoder:        "json",
+		OutputType:     "file",
+		Filename:       "./log/asyncrotated/app.log",
+		TimeRotation:   "daily",
+		AsyncQueueSize: 16,
+		AsyncOverflow:  logrotate.OverflowBlock,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create async rotating logger: %v", err)
+	}
+
+	for i := 0; i < 100; i++ {
+		logger.Info("async message", zap.Int("i", i))
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	today := time.Now().Format("2006-01-02")
+	data, err := os.ReadFile(filepath.Join("./log/asyncrotated", "app_"+today+".log"))
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 100 {
+		t.Errorf("expected 100 lines, got %d", lines)
+	}
+	if dropped := logger.(*zapLogger).DroppedCount(); dropped != 0 {
+		t.Errorf("expected no drops with block policy, got %d", dropped)
+	}
+}
+
+func TestSetLevelFor(t *testing.T) {
+	// 确保日志目录存在
+	os.MkdirAll("./log", 0755)
+	logFile := "./log/set_level_for.log"
+	os.Remove(logFile)
+
+	logger, err := NewZapLogger(LoggerConfig{
+		Level:      "info",
+		OutputPath: []string{logFile},
+		Encoder:    "json",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	setter, ok := logger.(HLevelSetter)
+	if !ok {
+		t.Fatal("logger does not implement HLevelSetter")
+	}
+
+	logger.Debug("debug before window")
+	setter.SetLevelFor("debug", 100*time.Millisecond)
+	logger.Debug("debug during window")
+
+	// 等待级别恢复
+	zl := logger.(*zapLogger)
+	deadline := time.Now().Add(2 * time.Second)
+	for zl.level.Level() != zap.InfoLevel && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	logger.Debug("debug after window")
+	logger.Close()
+
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	content := string(data)
+	if strings.Contains(content, "debug before window") || strings.Contains(content, "debug after window") {
+		t.Errorf("debug lines outside the window should be suppressed: %s", content)
+	}
+	if !strings.Contains(content, "debug during window") {
+		t.Errorf("debug line inside the window missing: %s", content)
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	// 确保日志目录存在
+	os.MkdirAll("./log", 0755)
+	os.RemoveAll("./log/setlevel")
+
+	zapLog, err := NewZapLogger(LoggerConfig{
+		Level:      "debug",
+		OutputPath: []string{"./log/setlevel/zap.log"},
+		Encoder:    "json",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	rotatingLog, err := NewRotatingLogger(RotateConfig{
+		Level:      "debug",
+		Encoder:    "json",
+		OutputType: "file",
+		Filename:   "./log/setlevel/rotating.log",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create rotating logger: %v", err)
+	}
+
+	loggers := map[string]HLogger{
+		"./log/setlevel/zap.log":      zapLog,
+		"./log/setlevel/rotating.log": rotatingLog,
+	}
+	for path, logger := range loggers {
+		setter, ok := logger.(HLevelSetter)
+		if !ok {
+			t.Fatalf("%s: logger does not implement HLevelSetter", path)
+		}
+
+		logger.Debug("debug before change")
+		if err := setter.SetLevel("warn"); err != nil {
+			t.Fatalf("%s: SetLevel failed: %v", path, err)
+		}
+		if got := setter.Level(); got != "warn" {
+			t.Errorf("%s: expected level warn, got %s", path, got)
+		}
+		if err := setter.SetLevel("verbose"); err == nil {
+			t.Errorf("%s: expected error for invalid level", path)
+		}
+		logger.Debug("debug after change")
+		logger.Info("info after change")
+		logger.Warn("warn after change")
+
+		data, err := SyncAndRead(logger, path)
+		if err != nil {
+			t.Fatalf("%s: Failed to read log file: %v", path, err)
+		}
+		content := string(data)
+		if !strings.Contains(content, "debug before change") || !strings.Contains(content, "warn after change") {
+			t.Errorf("%s: expected lines missing: %s", path, content)
+		}
+		if strings.Contains(content, "debug after change") || strings.Contains(content, "info after change") {
+			t.Errorf("%s: lines below warn should be suppressed: %s", path, content)
+		}
+		logger.Close()
+	}
+}
+
+func TestRotateConfigMerge(t *testing.T) {
+	base := RotateConfig{
+		TimeRotation: "daily",
+		MaxSize:      100,
+		MaxBackups:   7,
+		MaxAge:       30,
+		Filename:     "./log/base/app.log",
+		Level:        "info",
+		Encoder:      "json",
+		OutputType:   "file",
+	}
+
+	merged := base.Merge(RotateConfig{
+		Filename: "./log/base/db.log",
+		Level:    "warn",
+	})
+
+	if merged.Filename != "./log/base/db.log" || merged.Level != "warn" {
+		t.Errorf("override fields not applied: %+v", merged)
+	}
+	if merged.TimeRotation != "daily" || merged.MaxSize != 100 || merged.MaxBackups != 7 ||
+		merged.MaxAge != 30 || merged.Encoder != "json" || merged.OutputType != "file" {
+		t.Errorf("base fields not inherited: %+v", merged)
+	}
+	if base.Filename != "./log/base/app.log" || base.Level != "info" {
+		t.Errorf("base config should not be modified: %+v", base)
+	}
+}
+
+// TestNewZapLoggerOutputPathError 测试输出路径无法打开时返回错误
+func TestNewZapLoggerOutputPathError(t *testing.T) {
+	dir := t.TempDir()
+	// 以普通文件作为父目录, 使创建目录失败
+	blocker := filepath.Join(dir, "blocker")
+	if err := os.WriteFile(blocker, nil, 0644); err != nil {
+		t.Fatalf("write blocker failed: %v", err)
+	}
+	config := LoggerConfig{
+		Level:      "info",
+		OutputPath: []string{filepath.Join(dir, "ok.log"), filepath.Join(blocker, "app.log")},
+		Encoder:    "json",
+	}
+
+	logger, err := NewZapLogger(config)
+	if err == nil || logger != nil {
+		t.Fatalf("expected error for unopenable output path, got logger=%v err=%v", logger, err)
+	}
+	if !strings.Contains(err.Error(), "blocker") {
+		t.Errorf("expected error to mention the failed path, got %v", err)
+	}
+
+	// 回退模式保持旧行为
+	config.FallbackToStdout = true
+	logger, err = NewZapLogger(config)
+	if err != nil {
+		t.Fatalf("expected fallback to stdout, got %v", err)
+	}
+	logger.Close()
+}
+
+func TestLoggerWith(t *testing.T) {
+	// 确保日志目录存在
+	os.MkdirAll("./log", 0755)
+	logFile := "./log/logger_with.log"
+	os.Remove(logFile)
+
+	logger, err := NewZapLogger(LoggerConfig{
+		Level:      "info",
+		OutputPath: []string{logFile},
+		Encoder:    "json",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	child := logger.With(zap.String("request_id", "req-1"))
+	child.Info("child message")
+	// 子logger的Close不影响原logger
+	if err := child.Close(); err != nil {
+		t.Fatalf("child Close failed: %v", err)
+	}
+	logger.Info("parent message")
+
+	// 级别调整作用于共享的级别
+	child.(HLevelSetter).SetLevel("warn")
+	logger.Info("suppressed message")
+
+	data, err := SyncAndRead(logger, logFile)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %s", len(lines), data)
+	}
+	if !ContainsField([]byte(lines[0]), "request_id", "req-1") {
+		t.Errorf("child line missing request_id: %s", lines[0])
+	}
+	if strings.Contains(lines[1], "request_id") {
+		t.Errorf("parent line should not carry child fields: %s", lines[1])
+	}
+}
+
+func TestLoggerNamed(t *testing.T) {
+	// 确保日志目录存在
+	os.MkdirAll("./log", 0755)
+	logFile := "./log/logger_named.log"
+	os.Remove(logFile)
+
+	logger, err := NewZapLogger(LoggerConfig{
+		Level:      "info",
+		OutputPath: []string{logFile},
+		Encoder:    "json",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	access := logger.Named("access")
+	access.Info("access message")
+	access.Named("http").Info("nested message")
+	logger.Info("unnamed message")
+	slog.New(NewSlogHandler(logger.Named("svc"))).Info("slog message")
+
+	data, err := SyncAndRead(logger, logFile)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected 4 lines, got %d: %s", len(lines), data)
+	}
+	if !ContainsField([]byte(lines[0]), "logger", "access") {
+		t.Errorf("expected logger name access: %s", lines[0])
+	}
+	if !ContainsField([]byte(lines[1]), "logger", "access.http") {
+		t.Errorf("expected logger name access.http: %s", lines[1])
+	}
+	if strings.Contains(lines[2], `"logger"`) {
+		t.Errorf("unnamed logger should not emit a name: %s", lines[2])
+	}
+	if !ContainsField([]byte(lines[3]), "logger", "svc") {
+		t.Errorf("expected logger name svc from slog handler: %s", lines[3])
+	}
+}
+
+// logViaHelper 模拟业务代码对HLogger的封装
+func logViaHelper(l HLogger, msg string) {
+	l.Info(msg)
+}
+
+func TestCallerSkip(t *testing.T) {
+	// 确保日志目录存在
+	os.MkdirAll("./log", 0755)
+	logFile := "./log/caller_skip.log"
+	os.Remove(logFile)
+
+	logger, err := NewZapLogger(LoggerConfig{
+		Level:      "info",
+		OutputPath: []string{logFile},
+		Encoder:    "json",
+		CallerSkip: 1,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	_, _, line, _ := runtime.Caller(0)
+	logViaHelper(logger, "via helper")
+
+	data, err := SyncAndRead(logger, logFile)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	want := fmt.Sprintf("hlog/logger_test.go:%d", line+1)
+	if !ContainsField(data, "caller", want) {
+		t.Errorf("expected caller %s, got %s", want, data)
+	}
+}
+
+func TestSampling(t *testing.T) {
+	// 确保日志目录存在
+	os.MkdirAll("./log", 0755)
+	os.RemoveAll("./log/sampling")
+
+	zapLog, err := NewZapLogger(LoggerConfig{
+		Level:      "info",
+		OutputPath: []string{"./log/sampling/zap.log"},
+		Encoder:    "json",
+		Sampling:   &SamplingConfig{Initial: 10, Thereafter: 1000, Tick: time.Minute},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	rotatingLog, err := NewRotatingLogger(RotateConfig{
+		Level:      "info",
+		Encoder:    "json",
+		OutputType: "file",
+		Filename:   "./log/sampling/rotating.log",
+		Sampling:   &SamplingConfig{Initial: 10, Thereafter: 1000, Tick: time.Minute},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create rotating logger: %v", err)
+	}
+
+	loggers := map[string]HLogger{
+		"./log/sampling/zap.log":      zapLog,
+		"./log/sampling/rotating.log": rotatingLog,
+	}
+	for path, logger := range loggers {
+		for i := 0; i < 10000; i++ {
+			logger.Error("storm error")
+		}
+		data, err := SyncAndRead(logger, path)
+		if err != nil {
+			t.Fatalf("%s: Failed to read log file: %v", path, err)
+		}
+		// 10条初始 + 之后每1000条1条
+		if lines := strings.Count(string(data), "\n"); lines != 19 {
+			t.Errorf("%s: expected 19 sampled lines, got %d", path, lines)
+		}
+		logger.Close()
+	}
+}
+
+func TestCloseAll(t *testing.T) {
+	// 确保日志目录存在
+	os.MkdirAll("./log", 0755)
+	os.RemoveAll("./log/closeall")
+
+	InitLogger("closeall_plain", LoggerConfig{
+		Level:      "info",
+		OutputPath: []string{"./log/closeall/plain.log"},
+		Encoder:    "json",
+	})
+	InitRotatingLogger("closeall_async", RotateConfig{
+		Level:          "info",
+		Encoder:        "json",
+		OutputType:     "file",
+		Filename:       "./log/closeall/async.log",
+		AsyncQueueSize: 16,
+		AsyncOverflow:  logrotate.OverflowBlock,
+	})
+	async := GetLogger("closeall_async")
+	// 同一个logger注册在多个类型下只关闭一次
+	SetLogger("closeall_alias", async)
+
+	GetLogger("closeall_plain").Info("plain message")
+	for i := 0; i < 50; i++ {
+		async.Info("async message")
+	}
+
+	if err := CloseAll(); err != nil {
+		t.Fatalf("CloseAll failed: %v", err)
+	}
+
+	plain, _ := os.ReadFile("./log/closeall/plain.log")
+	if !strings.Contains(string(plain), "plain message") {
+		t.Errorf("plain logger not flushed: %s", plain)
+	}
+	asyncData, _ := os.ReadFile("./log/closeall/async.log")
+	if lines := strings.Count(string(asyncData), "\n"); lines != 50 {
+		t.Errorf("expected 50 async lines, got %d", lines)
+	}
+
+	if got := GetLogger("closeall_async"); got == async {
+		t.Error("expected GetLogger to return the default logger after CloseAll")
+	}
+	if len(GlobalLoggers) != 0 {
+		t.Errorf("expected GlobalLoggers to be empty, got %d", len(GlobalLoggers))
+	}
+}
+
+func TestLevelSinks(t *testing.T) {
+	// 确保日志目录存在
+	os.MkdirAll("./log", 0755)
+	os.RemoveAll("./log/levelsinks")
+	infoFile := "./log/levelsinks/info.log"
+	errorFile := "./log/levelsinks/error.log"
+
+	logger, err := NewZapLogger(LoggerConfig{
+		Level:   "info",
+		Encoder: "json",
+		LevelSinks: []LevelSink{
+			{MaxLevel: "info", OutputPath: []string{infoFile}},
+			{MinLevel: "warn", OutputPath: []string{errorFile}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	logger.Debug("debug line")
+	logger.Info("info line")
+	logger.Error("error line")
+
+	infoData, err := SyncAndRead(logger, infoFile)
+	if err != nil {
+		t.Fatalf("Failed to read info file: %v", err)
+	}
+	errorData, err := os.ReadFile(errorFile)
+	if err != nil {
+		t.Fatalf("Failed to read error file: %v", err)
+	}
+	if got := strings.TrimSpace(string(infoData)); !strings.Contains(got, "info line") || strings.Count(got, "\n") != 0 {
+		t.Errorf("info file should contain only the info line: %s", infoData)
+	}
+	if got := strings.TrimSpace(string(errorData)); !strings.Contains(got, "error line") || strings.Count(got, "\n") != 0 {
+		t.Errorf("error file should contain only the error line: %s", errorData)
+	}
+
+	if _, err := NewZapLogger(LoggerConfig{LevelSinks: []LevelSink{{MinLevel: "loud"}}}); err == nil {
+		t.Error("expected error for invalid sink level")
+	}
+
+	// 后面的输出失败时关闭已打开的文件
+	if fds, err := os.ReadDir("/proc/self/fd"); err == nil {
+		dir := t.TempDir()
+		blocker := filepath.Join(dir, "blocker")
+		os.WriteFile(blocker, nil, 0644)
+		for _, sinks := range [][]LevelSink{
+			{{MinLevel: "warn", OutputPath: []string{filepath.Join(dir, "warn.log")}}, {MinLevel: "loud"}},
+			{{MinLevel: "warn", OutputPath: []string{filepath.Join(dir, "warn.log")}}, {MinLevel: "error", OutputPath: []string{filepath.Join(blocker, "error.log")}}},
+		} {
+			if _, err := NewZapLogger(LoggerConfig{OutputPath: []string{filepath.Join(dir, "main.log")}, LevelSinks: sinks}); err == nil {
+				t.Error("expected error for invalid sink")
+			}
+		}
+		if after, _ := os.ReadDir("/proc/self/fd"); len(after) != len(fds) {
+			t.Errorf("expected opened files to be closed, open fds %d -> %d", len(fds), len(after))
+		}
+	}
+}
+
+func TestStderrOutputPath(t *testing.T) {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("chdir failed: %v", err)
+	}
+	defer os.Chdir(wd)
+
+	logger, err := NewZapLogger(LoggerConfig{
+		Level:      "info",
+		OutputPath: []string{"stderr"},
+		Encoder:    "console",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	logger.Info("message to stderr")
+
+	if _, err := os.Stat(filepath.Join(dir, "stderr")); !os.IsNotExist(err) {
+		t.Error("stderr should not be treated as a file path")
+	}
+}
+
+func TestSyncKeepsLoggerUsable(t *testing.T) {
+	// 确保日志目录存在
+	os.MkdirAll("./log", 0755)
+	os.RemoveAll("./log/sync")
+	logFile := "./log/sync/app.log"
+
+	logger, err := NewRotatingLogger(RotateConfig{
+		Level:          "info",
+		Encoder:        "json",
+		OutputType:     "file",
+		Filename:       logFile,
+		AsyncQueueSize: 16,
+		AsyncOverflow:  logrotate.OverflowBlock,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	logger.Info("before sync")
+	if err := logger.Sync(); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	data, _ := os.ReadFile(logFile)
+	if !strings.Contains(string(data), "before sync") {
+		t.Errorf("expected line to be flushed by Sync: %s", data)
+	}
+
+	logger.Info("after sync")
+	if err := logger.Sync(); err != nil {
+		t.Fatalf("second Sync failed: %v", err)
+	}
+	data, _ = os.ReadFile(logFile)
+	if !strings.Contains(string(data), "after sync") {
+		t.Errorf("logger should remain usable after Sync: %s", data)
+	}
+}
+
+func TestConsoleSplitOutput(t *testing.T) {
+	dir := t.TempDir()
+
+	// 用临时文件替换标准输出和标准错误, 检查分流结果
+	stdout, _ := os.Create(filepath.Join(dir, "stdout"))
+	stderr, _ := os.Create(filepath.Join(dir, "stderr"))
+	origStdout, origStderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = stdout, stderr
+	defer func() { os.Stdout, os.Stderr = origStdout, origStderr }()
+
+	logFile := filepath.Join(dir, "app.log")
+	logger, err := NewRotatingLogger(RotateConfig{
+		Level:      "info",
+		Encoder:    "json",
+		OutputType: "console-split",
+		Filename:   logFile,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	logger.Debug("debug line")
+	logger.Info("info line")
+	logger.Error("error line")
+	logger.Close()
+
+	outData, _ := os.ReadFile(stdout.Name())
+	errData, _ := os.ReadFile(stderr.Name())
+	fileData, _ := os.ReadFile(logFile)
+	if !strings.Contains(string(outData), "info line") || strings.Contains(string(outData), "error line") {
+		t.Errorf("stdout should contain only the info line: %s", outData)
+	}
+	if !strings.Contains(string(errData), "error line") || strings.Contains(string(errData), "info line") {
+		t.Errorf("stderr should contain only the error line: %s", errData)
+	}
+	if !strings.Contains(string(fileData), "info line") || !strings.Contains(string(fileData), "error line") {
+		t.Errorf("file should contain all lines: %s", fileData)
+	}
+	if strings.Contains(string(outData)+string(errData)+string(fileData), "debug line") {
+		t.Error("debug line should be filtered by Level")
+	}
+}
+
+func TestInitRotatingLoggerE(t *testing.T) {
+	dir := t.TempDir()
+	// 父路径是普通文件, 无法创建日志目录
+	blocker := filepath.Join(dir, "blocker")
+	if err := os.WriteFile(blocker, nil, 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	err := InitRotatingLoggerE("init_e_invalid", RotateConfig{
+		Level:      "info",
+		Encoder:    "json",
+		OutputType: "file",
+		Filename:   filepath.Join(blocker, "app.log"),
+	})
+	if err == nil {
+		t.Fatal("expected error for invalid filename")
+	}
+	if _, ok := GetLoggerOK("init_e_invalid"); ok {
+		t.Error("logger type should not be set after a failed init")
+	}
+
+	if err := InitLoggerE("init_e_valid", LoggerConfig{Level: "info", OutputPath: []string{filepath.Join(dir, "app.log")}}); err != nil {
+		t.Fatalf("InitLoggerE failed: %v", err)
+	}
+	logger, ok := GetLoggerOK("init_e_valid")
+	if !ok {
+		t.Fatal("expected logger type to be set")
+	}
+	logger.Close()
+	loggersMutex.Lock()
+	delete(GlobalLoggers, "init_e_valid")
+	loggersMutex.Unlock()
+}
+
+func TestInitialFields(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "app.log")
+	logger, err := NewRotatingLogger(RotateConfig{
+		Level:      "info",
+		Encoder:    "json",
+		OutputType: "file",
+		Filename:   logFile,
+		InitialFields: map[string]interface{}{
+			"service": "payments",
+			"env":     "prod",
+			"shard":   3,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	logger.Info("no explicit fields")
+	data, err := SyncAndRead(logger, logFile)
+	if err != nil {
+		t.Fatalf("SyncAndRead failed: %v", err)
+	}
+	if !ContainsField(data, "service", "payments") || !ContainsField(data, "env", "prod") || !ContainsField(data, "shard", "3") {
+		t.Errorf("expected static fields in output: %s", data)
+	}
+}
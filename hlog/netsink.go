@@ -0,0 +1,194 @@
+package hlog
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	netDialTimeout   = 3 * time.Second // 建立连接的超时时间
+	netWriteTimeout  = 3 * time.Second // 单次发送的超时时间
+	netRetryInterval = time.Second     // 两次连接尝试的最小间隔
+	netMaxPending    = 4 * 1024 * 1024 // 未发送数据的最大缓存字节数, 超出时丢弃最早的数据
+)
+
+// netSchemes 支持的网络输出协议
+var netSchemes = map[string]bool{
+	"tcp": true, "tcp4": true, "tcp6": true,
+	"udp": true, "udp4": true, "udp6": true,
+}
+
+// parseNetPath 解析 tcp://host:port 或 udp://host:port 形式的输出路径
+func parseNetPath(path string) (network, addr string, ok bool) {
+	network, addr, found := strings.Cut(path, "://")
+	if !found || addr == "" || !netSchemes[network] {
+		return "", "", false
+	}
+	return network, addr, true
+}
+
+// netWriteSyncer 将日志发送到TCP/UDP地址的WriteSyncer
+//
+// Write只将数据放入缓存并通知后台协程, 连接、重连和发送都由后台协程完成, 网络故障不会阻塞业务日志;
+// 发送失败时断开连接并保留未发送的部分, 按重连间隔重连并补发; 写入本身不返回错误
+type netWriteSyncer struct {
+	network string
+	addr    string
+
+	mu      sync.Mutex
+	pending [][]byte
+	size    int
+
+	notify    chan struct{} // 有新数据时通知后台协程
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+
+	// 以下字段只由后台协程访问
+	conn     net.Conn
+	lastDial time.Time
+}
+
+// newNetWriteSyncer 创建网络输出并启动后台发送协程, 连接在后台建立, 连接失败时自动重连
+func newNetWriteSyncer(network, addr string) *netWriteSyncer {
+	w := &netWriteSyncer{
+		network: network,
+		addr:    addr,
+		notify:  make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Write 实现io.Writer接口
+func (w *netWriteSyncer) Write(p []byte) (int, error) {
+	// zap会复用p, 需要复制
+	buf := make([]byte, len(p))
+	copy(buf, p)
+
+	w.mu.Lock()
+	w.pending = append(w.pending, buf)
+	w.size += len(buf)
+	w.trimLocked()
+	w.mu.Unlock()
+
+	w.wake()
+	return len(p), nil
+}
+
+// Sync 通知后台协程发送缓存的数据, 不等待发送完成
+func (w *netWriteSyncer) Sync() error {
+	w.wake()
+	return nil
+}
+
+// Close 停止后台协程并关闭连接, 关闭前通过已有连接尽量发送缓存的数据, 仍有未发送的数据时返回错误
+func (w *netWriteSyncer) Close() error {
+	w.closeOnce.Do(func() {
+		close(w.stop)
+	})
+	<-w.done
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.size > 0 {
+		return fmt.Errorf("net sink %s://%s: %d bytes not sent", w.network, w.addr, w.size)
+	}
+	return nil
+}
+
+// wake 通知后台协程, 已有未处理的通知时跳过
+func (w *netWriteSyncer) wake() {
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+}
+
+// trimLocked 缓存超过netMaxPending时丢弃最早的数据, 调用方需持有w.mu
+func (w *netWriteSyncer) trimLocked() {
+	for w.size > netMaxPending && len(w.pending) > 1 {
+		w.size -= len(w.pending[0])
+		w.pending[0] = nil
+		w.pending = w.pending[1:]
+	}
+}
+
+// run 后台发送协程, 收到通知或每隔重连间隔发送一次缓存的数据
+func (w *netWriteSyncer) run() {
+	defer close(w.done)
+	ticker := time.NewTicker(netRetryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.notify:
+		case <-ticker.C:
+		case <-w.stop:
+			// 不再重连, 避免Close长时间阻塞
+			w.flush(false)
+			if w.conn != nil {
+				w.conn.Close()
+				w.conn = nil
+			}
+			return
+		}
+		w.flush(true)
+	}
+}
+
+// connect 建立连接, 距上次尝试不足重连间隔时跳过
+func (w *netWriteSyncer) connect() bool {
+	if w.conn != nil {
+		return true
+	}
+	if !w.lastDial.IsZero() && time.Since(w.lastDial) < netRetryInterval {
+		return false
+	}
+	w.lastDial = time.Now()
+	conn, err := net.DialTimeout(w.network, w.addr, netDialTimeout)
+	if err != nil {
+		return false
+	}
+	w.conn = conn
+	return true
+}
+
+// flush 依次发送缓存的数据, dial为false时只使用已有连接; 失败时断开连接并将未发送的部分放回缓存, 避免重连后重复发送
+func (w *netWriteSyncer) flush(dial bool) {
+	for {
+		if w.conn == nil && (!dial || !w.connect()) {
+			return
+		}
+
+		w.mu.Lock()
+		if len(w.pending) == 0 {
+			w.mu.Unlock()
+			return
+		}
+		buf := w.pending[0]
+		w.pending[0] = nil
+		w.pending = w.pending[1:]
+		w.size -= len(buf)
+		w.mu.Unlock()
+
+		w.conn.SetWriteDeadline(time.Now().Add(netWriteTimeout))
+		if n, err := w.conn.Write(buf); err != nil {
+			w.conn.Close()
+			w.conn = nil
+
+			if rest := buf[n:]; len(rest) > 0 {
+				w.mu.Lock()
+				w.pending = append([][]byte{rest}, w.pending...)
+				w.size += len(rest)
+				w.trimLocked()
+				w.mu.Unlock()
+			}
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package hlog
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestNetSinkTCP 测试日志发送到TCP地址
+func TestNetSinkTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer ln.Close()
+
+	lines := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				scanner := bufio.NewScanner(conn)
+				for scanner.Scan() {
+					lines <- scanner.Text()
+				}
+			}()
+		}
+	}()
+
+	logger, err := NewZapLogger(LoggerConfig{
+		Level:      "info",
+		OutputPath: []string{"tcp://" + ln.Addr().String()},
+		Encoder:    "json",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	logger.Info("tcp message")
+	select {
+	case line := <-lines:
+		if !ContainsField([]byte(line), "msg", "tcp message") {
+			t.Errorf("unexpected line: %s", line)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for tcp message")
+	}
+}
+
+// TestNetSinkUDP 测试日志发送到UDP地址
+func TestNetSinkUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer pc.Close()
+
+	logger, err := NewZapLogger(LoggerConfig{
+		Level:      "info",
+		OutputPath: []string{"udp://" + pc.LocalAddr().String()},
+		Encoder:    "json",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	logger.Info("udp message")
+	pc.SetReadDeadline(time.Now().Add(2 * time.Second))
+	buf := make([]byte, 4096)
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if line := strings.TrimSpace(string(buf[:n])); !ContainsField([]byte(line), "msg", "udp message") {
+		t.Errorf("unexpected datagram: %s", line)
+	}
+}
+
+// TestNetSinkUnavailable 测试目标不可达时写入不报错、不阻塞并缓存数据
+func TestNetSinkUnavailable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	w := newNetWriteSyncer("tcp", addr)
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		if n, err := w.Write([]byte("buffered\n")); err != nil || n != len("buffered\n") {
+			t.Fatalf("Write should not fail: n=%d err=%v", n, err)
+		}
+	}
+	if err := w.Sync(); err != nil {
+		t.Fatalf("Sync should not fail: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("Write should not wait for the network, took %v", elapsed)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	w.mu.Lock()
+	pending := len(w.pending)
+	w.mu.Unlock()
+	if pending != 100 {
+		t.Errorf("expected 100 pending entries, got %d", pending)
+	}
+
+	// 关闭时报告未发送的数据
+	if err := w.Close(); err == nil || !strings.Contains(err.Error(), "900 bytes not sent") {
+		t.Errorf("expected Close to report unsent bytes, got %v", err)
+	}
+}
+
+// partialConn 模拟对端在发送中途关闭: 写入limit字节后返回错误
+type partialConn struct {
+	net.Conn
+	out   *bytes.Buffer
+	limit int
+}
+
+func (c *partialConn) Write(p []byte) (int, error) {
+	if c.limit >= 0 && len(p) > c.limit {
+		n := c.limit
+		c.out.Write(p[:n])
+		c.limit = 0
+		return n, io.ErrClosedPipe
+	}
+	c.out.Write(p)
+	if c.limit >= 0 {
+		c.limit -= len(p)
+	}
+	return len(p), nil
+}
+
+func (c *partialConn) SetWriteDeadline(time.Time) error { return nil }
+
+func (c *partialConn) Close() error { return nil }
+
+// TestNetSinkPartialWrite 测试连接在发送中途断开后, 重连只补发未发送的部分, 不重复发送
+func TestNetSinkPartialWrite(t *testing.T) {
+	// 不启动后台协程, 由测试直接驱动flush
+	w := &netWriteSyncer{network: "tcp", addr: "127.0.0.1:0", notify: make(chan struct{}, 1)}
+	lines := []string{"line-1\n", "line-2\n", "line-3\n"}
+	for _, line := range lines {
+		w.Write([]byte(line))
+	}
+
+	var out bytes.Buffer
+	w.conn = &partialConn{out: &out, limit: len(lines[0]) + 3}
+	w.flush(false)
+	if w.conn != nil {
+		t.Fatal("expected connection to be dropped after a failed write")
+	}
+	if w.size != len(lines[1])-3+len(lines[2]) {
+		t.Errorf("expected only unsent bytes to be pending, got %d", w.size)
+	}
+
+	w.conn = &partialConn{out: &out, limit: -1}
+	w.flush(false)
+	if got := out.String(); got != strings.Join(lines, "") {
+		t.Errorf("expected each line exactly once, got %q", got)
+	}
+}
+
+// TestNetSinkClose 测试关闭logger时关闭网络连接
+func TestNetSinkClose(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer ln.Close()
+
+	logger, err := NewZapLogger(LoggerConfig{
+		Level:      "info",
+		OutputPath: []string{"tcp://" + ln.Addr().String()},
+		Encoder:    "json",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	logger.Info("before close")
+
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("accept failed: %v", err)
+	}
+	defer conn.Close()
+	logger.Close()
+
+	// 连接关闭后读到已发送的数据和EOF
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	data, err := io.ReadAll(conn)
+	if err != nil {
+		t.Fatalf("expected connection closed by logger, got %v", err)
+	}
+	if !strings.Contains(string(data), "before close") {
+		t.Errorf("unexpected data: %s", data)
+	}
+}
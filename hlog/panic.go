@@ -0,0 +1,50 @@
+package hlog
+
+import (
+	"fmt"
+	"os"
+	"runtime/debug"
+)
+
+// InstallPanicLogger 将未恢复panic的崩溃输出(包含堆栈)追加写入指定类型logger的日志文件
+//
+// 限制:
+//   - 运行时崩溃时直接写文件描述符, 无法经过zap编码, 写入的是原始文本而非结构化日志
+//   - 只写入logger的第一个文件输出; 轮转logger使用安装时的当前文件, 轮转后需重新调用
+//   - 崩溃输出仍会同时写到标准错误; 同一进程只能有一个崩溃输出文件, 重复调用会覆盖
+//   - 不修改堆栈的详细程度, 需要所有goroutine的堆栈时设置环境变量GOTRACEBACK=all或调用debug.SetTraceback
+func InstallPanicLogger(loggerType string) error {
+	zl, ok := GetLogger(loggerType).(*zapLogger)
+	if !ok {
+		return fmt.Errorf("logger %q is not a zap logger", loggerType)
+	}
+
+	path := zl.filePath()
+	if path == "" {
+		return fmt.Errorf("logger %q has no file output", loggerType)
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	// SetCrashOutput会复制文件描述符, 返回后即可关闭
+	defer file.Close()
+
+	return debug.SetCrashOutput(file, debug.CrashOptions{})
+}
+
+// filePath 返回logger的第一个文件输出路径
+func (zl *zapLogger) filePath() string {
+	if zl.rotateWriter != nil {
+		return zl.rotateWriter.GetLogFilePath()
+	}
+	if zl.config != nil {
+		for _, path := range zl.config.OutputPath {
+			if isFilePath(path) {
+				return path
+			}
+		}
+	}
+	return ""
+}
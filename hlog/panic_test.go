@@ -0,0 +1,76 @@
+package hlog
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestInstallPanicLogger 在子进程中触发panic, 验证堆栈写入日志文件
+func TestInstallPanicLogger(t *testing.T) {
+	if logFile := os.Getenv("HLOG_PANIC_LOG_FILE"); logFile != "" {
+		InitLogger("panic", LoggerConfig{
+			Level:      "info",
+			OutputPath: []string{logFile},
+			Encoder:    "json",
+		})
+		if err := InstallPanicLogger("panic"); err != nil {
+			t.Fatalf("InstallPanicLogger failed: %v", err)
+		}
+		GetLogger("panic").Info("before panic")
+		panic("boom from panic logger test")
+	}
+
+	logFile := filepath.Join(t.TempDir(), "panic.log")
+	cmd := exec.Command(os.Args[0], "-test.run=^TestInstallPanicLogger$")
+	cmd.Env = append(os.Environ(), "HLOG_PANIC_LOG_FILE="+logFile)
+	if err := cmd.Run(); err == nil {
+		t.Fatal("expected child process to crash")
+	}
+
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	content := string(data)
+	if !strings.Contains(content, "before panic") {
+		t.Errorf("log file missing normal entry: %s", content)
+	}
+	if !strings.Contains(content, "panic: boom from panic logger test") || !strings.Contains(content, "goroutine") {
+		t.Errorf("log file missing panic stack: %s", content)
+	}
+}
+
+// TestPanicLoggerFilePath 测试崩溃输出只使用文件路径, 跳过标准输出、标准错误和网络地址
+func TestPanicLoggerFilePath(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "app.log")
+	logger, err := NewZapLogger(LoggerConfig{OutputPath: []string{"stderr", "udp://127.0.0.1:9", logFile}})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+	if got := logger.(*zapLogger).filePath(); got != logFile {
+		t.Errorf("expected %s, got %q", logFile, got)
+	}
+
+	if err := InitLoggerE("panic_no_file", LoggerConfig{OutputPath: []string{"stderr", "tcp://127.0.0.1:9"}}); err != nil {
+		t.Fatalf("InitLoggerE failed: %v", err)
+	}
+	t.Cleanup(func() {
+		loggersMutex.Lock()
+		noFile := GlobalLoggers["panic_no_file"]
+		delete(GlobalLoggers, "panic_no_file")
+		loggersMutex.Unlock()
+		noFile.Close()
+	})
+	if err := InstallPanicLogger("panic_no_file"); err == nil {
+		t.Error("expected error for logger without file output")
+	}
+	for _, name := range []string{"stderr", "tcp:"} {
+		if _, err := os.Stat(name); err == nil {
+			t.Errorf("unexpected file %q created", name)
+		}
+	}
+}
@@ -0,0 +1,398 @@
+package hlog
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// redactMask 脱敏后的替换内容
+const redactMask = "***"
+
+var redactBufferPool = buffer.NewPool()
+
+// compileRedactPatterns 编译脱敏正则
+func compileRedactPatterns(patterns []string) ([]*regexp.Regexp, error) {
+	regs := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		reg, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		regs = append(regs, reg)
+	}
+	return regs, nil
+}
+
+// redactEncoder 对编码后的整行日志(包括消息和所有字段)做正则替换的编码器
+//
+// 替换作用于编码结果, 正则应避免匹配引号等结构字符, 否则可能破坏json格式
+type redactEncoder struct {
+	zapcore.Encoder
+	patterns []*regexp.Regexp
+}
+
+// newRedactEncoder 包装编码器, 没有正则时返回原编码器
+func newRedactEncoder(enc zapcore.Encoder, patterns []*regexp.Regexp) zapcore.Encoder {
+	if len(patterns) == 0 {
+		return enc
+	}
+	return &redactEncoder{Encoder: enc, patterns: patterns}
+}
+
+// Clone 实现zapcore.Encoder接口
+func (e *redactEncoder) Clone() zapcore.Encoder {
+	return &redactEncoder{Encoder: e.Encoder.Clone(), patterns: e.patterns}
+}
+
+// EncodeEntry 实现zapcore.Encoder接口
+func (e *redactEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	buf, err := e.Encoder.EncodeEntry(ent, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	line := buf.Bytes()
+	matched := false
+	for _, reg := range e.patterns {
+		if reg.Match(line) {
+			line = reg.ReplaceAll(line, []byte(redactMask))
+			matched = true
+		}
+	}
+	if !matched {
+		return buf, nil
+	}
+
+	out := redactBufferPool.Get()
+	out.Write(line)
+	buf.Free()
+	return out, nil
+}
+
+// redactKeySet 需要脱敏的字段名集合, 不区分大小写
+type redactKeySet map[string]struct{}
+
+// newRedactKeySet 创建字段名集合, 没有字段名时返回nil
+func newRedactKeySet(keys []string) redactKeySet {
+	if len(keys) == 0 {
+		return nil
+	}
+	set := make(redactKeySet, len(keys))
+	for _, key := range keys {
+		set[strings.ToLower(key)] = struct{}{}
+	}
+	return set
+}
+
+// match 字段名是否需要脱敏
+func (s redactKeySet) match(key string) bool {
+	_, ok := s[strings.ToLower(key)]
+	return ok
+}
+
+// redactField 将匹配的字段替换为 ***, 对象字段和字符串键的map按键递归处理; 字段被替换时返回true
+func (s redactKeySet) redactField(f zapcore.Field) (zapcore.Field, bool) {
+	if f.Type != zapcore.NamespaceType && s.match(f.Key) {
+		return zap.String(f.Key, redactMask), true
+	}
+
+	switch f.Type {
+	case zapcore.ObjectMarshalerType:
+		if obj, ok := f.Interface.(zapcore.ObjectMarshaler); ok {
+			f.Interface = redactObject{ObjectMarshaler: obj, keys: s}
+			return f, true
+		}
+	case zapcore.ReflectType:
+		rv := reflect.ValueOf(f.Interface)
+		if rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String {
+			return zap.Object(f.Key, redactMap{value: rv, keys: s}), true
+		}
+	}
+	return f, false
+}
+
+// redactFields 返回脱敏后的字段, 没有需要处理的字段时返回原切片
+func (s redactKeySet) redactFields(fields []zapcore.Field) []zapcore.Field {
+	var out []zapcore.Field
+	for i, f := range fields {
+		rf, changed := s.redactField(f)
+		if out == nil {
+			if !changed {
+				continue
+			}
+			out = make([]zapcore.Field, len(fields))
+			copy(out, fields[:i])
+		}
+		out[i] = rf
+	}
+	if out == nil {
+		return fields
+	}
+	return out
+}
+
+// redactKeyCore 在字段到达编码器之前按字段名脱敏的zapcore.Core
+//
+// 只能包装zapcore.NewCore等单一级别的core: 包装Tee时Write会写入所有子core而不再检查各自的级别
+type redactKeyCore struct {
+	zapcore.Core
+	keys redactKeySet
+}
+
+// newRedactKeyCore 包装core, 没有字段名时返回原core
+func newRedactKeyCore(core zapcore.Core, keys []string) zapcore.Core {
+	set := newRedactKeySet(keys)
+	if set == nil {
+		return core
+	}
+	return &redactKeyCore{Core: core, keys: set}
+}
+
+// redactKeysOf 返回config中需要脱敏的字段名, config为nil时返回nil
+func redactKeysOf(config *EncoderConfig) []string {
+	if config == nil {
+		return nil
+	}
+	return config.RedactKeys
+}
+
+// With 实现zapcore.Core接口
+func (c *redactKeyCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactKeyCore{Core: c.Core.With(c.keys.redactFields(fields)), keys: c.keys}
+}
+
+// Check 实现zapcore.Core接口
+func (c *redactKeyCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write 实现zapcore.Core接口
+func (c *redactKeyCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(ent, c.keys.redactFields(fields))
+}
+
+// redactObject 编码时按字段名脱敏的对象
+type redactObject struct {
+	zapcore.ObjectMarshaler
+	keys redactKeySet
+}
+
+// MarshalLogObject 实现zapcore.ObjectMarshaler接口
+func (o redactObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	return o.ObjectMarshaler.MarshalLogObject(&redactObjectEncoder{ObjectEncoder: enc, keys: o.keys})
+}
+
+// redactMap 编码时按键脱敏的字符串键map
+type redactMap struct {
+	value reflect.Value
+	keys  redactKeySet
+}
+
+// MarshalLogObject 实现zapcore.ObjectMarshaler接口
+func (m redactMap) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	iter := m.value.MapRange()
+	for iter.Next() {
+		f, _ := m.keys.redactField(zap.Any(iter.Key().String(), iter.Value().Interface()))
+		f.AddTo(enc)
+	}
+	return nil
+}
+
+// redactObjectEncoder 将匹配字段名的值替换为 *** 的ObjectEncoder
+type redactObjectEncoder struct {
+	zapcore.ObjectEncoder
+	keys redactKeySet
+}
+
+// mask 字段名匹配时写入 *** 并返回true
+func (e *redactObjectEncoder) mask(key string) bool {
+	if e.keys.match(key) {
+		e.ObjectEncoder.AddString(key, redactMask)
+		return true
+	}
+	return false
+}
+
+// AddArray 实现zapcore.ObjectEncoder接口
+func (e *redactObjectEncoder) AddArray(key string, v zapcore.ArrayMarshaler) error {
+	if e.mask(key) {
+		return nil
+	}
+	return e.ObjectEncoder.AddArray(key, v)
+}
+
+// AddObject 实现zapcore.ObjectEncoder接口
+func (e *redactObjectEncoder) AddObject(key string, v zapcore.ObjectMarshaler) error {
+	if e.mask(key) {
+		return nil
+	}
+	return e.ObjectEncoder.AddObject(key, redactObject{ObjectMarshaler: v, keys: e.keys})
+}
+
+// AddBinary 实现zapcore.ObjectEncoder接口
+func (e *redactObjectEncoder) AddBinary(key string, v []byte) {
+	if !e.mask(key) {
+		e.ObjectEncoder.AddBinary(key, v)
+	}
+}
+
+// AddByteString 实现zapcore.ObjectEncoder接口
+func (e *redactObjectEncoder) AddByteString(key string, v []byte) {
+	if !e.mask(key) {
+		e.ObjectEncoder.AddByteString(key, v)
+	}
+}
+
+// AddBool 实现zapcore.ObjectEncoder接口
+func (e *redactObjectEncoder) AddBool(key string, v bool) {
+	if !e.mask(key) {
+		e.ObjectEncoder.AddBool(key, v)
+	}
+}
+
+// AddComplex128 实现zapcore.ObjectEncoder接口
+func (e *redactObjectEncoder) AddComplex128(key string, v complex128) {
+	if !e.mask(key) {
+		e.ObjectEncoder.AddComplex128(key, v)
+	}
+}
+
+// AddComplex64 实现zapcore.ObjectEncoder接口
+func (e *redactObjectEncoder) AddComplex64(key string, v complex64) {
+	if !e.mask(key) {
+		e.ObjectEncoder.AddComplex64(key, v)
+	}
+}
+
+// AddDuration 实现zapcore.ObjectEncoder接口
+func (e *redactObjectEncoder) AddDuration(key string, v time.Duration) {
+	if !e.mask(key) {
+		e.ObjectEncoder.AddDuration(key, v)
+	}
+}
+
+// AddFloat64 实现zapcore.ObjectEncoder接口
+func (e *redactObjectEncoder) AddFloat64(key string, v float64) {
+	if !e.mask(key) {
+		e.ObjectEncoder.AddFloat64(key, v)
+	}
+}
+
+// AddFloat32 实现zapcore.ObjectEncoder接口
+func (e *redactObjectEncoder) AddFloat32(key string, v float32) {
+	if !e.mask(key) {
+		e.ObjectEncoder.AddFloat32(key, v)
+	}
+}
+
+// AddInt 实现zapcore.ObjectEncoder接口
+func (e *redactObjectEncoder) AddInt(key string, v int) {
+	if !e.mask(key) {
+		e.ObjectEncoder.AddInt(key, v)
+	}
+}
+
+// AddInt64 实现zapcore.ObjectEncoder接口
+func (e *redactObjectEncoder) AddInt64(key string, v int64) {
+	if !e.mask(key) {
+		e.ObjectEncoder.AddInt64(key, v)
+	}
+}
+
+// AddInt32 实现zapcore.ObjectEncoder接口
+func (e *redactObjectEncoder) AddInt32(key string, v int32) {
+	if !e.mask(key) {
+		e.ObjectEncoder.AddInt32(key, v)
+	}
+}
+
+// AddInt16 实现zapcore.ObjectEncoder接口
+func (e *redactObjectEncoder) AddInt16(key string, v int16) {
+	if !e.mask(key) {
+		e.ObjectEncoder.AddInt16(key, v)
+	}
+}
+
+// AddInt8 实现zapcore.ObjectEncoder接口
+func (e *redactObjectEncoder) AddInt8(key string, v int8) {
+	if !e.mask(key) {
+		e.ObjectEncoder.AddInt8(key, v)
+	}
+}
+
+// AddString 实现zapcore.ObjectEncoder接口
+func (e *redactObjectEncoder) AddString(key, v string) {
+	if !e.mask(key) {
+		e.ObjectEncoder.AddString(key, v)
+	}
+}
+
+// AddTime 实现zapcore.ObjectEncoder接口
+func (e *redactObjectEncoder) AddTime(key string, v time.Time) {
+	if !e.mask(key) {
+		e.ObjectEncoder.AddTime(key, v)
+	}
+}
+
+// AddUint 实现zapcore.ObjectEncoder接口
+func (e *redactObjectEncoder) AddUint(key string, v uint) {
+	if !e.mask(key) {
+		e.ObjectEncoder.AddUint(key, v)
+	}
+}
+
+// AddUint64 实现zapcore.ObjectEncoder接口
+func (e *redactObjectEncoder) AddUint64(key string, v uint64) {
+	if !e.mask(key) {
+		e.ObjectEncoder.AddUint64(key, v)
+	}
+}
+
+// AddUint32 实现zapcore.ObjectEncoder接口
+func (e *redactObjectEncoder) AddUint32(key string, v uint32) {
+	if !e.mask(key) {
+		e.ObjectEncoder.AddUint32(key, v)
+	}
+}
+
+// AddUint16 实现zapcore.ObjectEncoder接口
+func (e *redactObjectEncoder) AddUint16(key string, v uint16) {
+	if !e.mask(key) {
+		e.ObjectEncoder.AddUint16(key, v)
+	}
+}
+
+// AddUint8 实现zapcore.ObjectEncoder接口
+func (e *redactObjectEncoder) AddUint8(key string, v uint8) {
+	if !e.mask(key) {
+		e.ObjectEncoder.AddUint8(key, v)
+	}
+}
+
+// AddUintptr 实现zapcore.ObjectEncoder接口
+func (e *redactObjectEncoder) AddUintptr(key string, v uintptr) {
+	if !e.mask(key) {
+		e.ObjectEncoder.AddUintptr(key, v)
+	}
+}
+
+// AddReflected 实现zapcore.ObjectEncoder接口
+func (e *redactObjectEncoder) AddReflected(key string, v interface{}) error {
+	if e.mask(key) {
+		return nil
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String {
+		return e.ObjectEncoder.AddObject(key, redactMap{value: rv, keys: e.keys})
+	}
+	return e.ObjectEncoder.AddReflected(key, v)
+}
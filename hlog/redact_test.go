@@ -0,0 +1,192 @@
+package hlog
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// TestRedactPatterns 测试按正则对日志内容脱敏
+func TestRedactPatterns(t *testing.T) {
+	// 确保日志目录存在
+	os.MkdirAll("./log", 0755)
+	logFile := "./log/redact_patterns.log"
+	os.Remove(logFile)
+
+	logger, err := NewZapLogger(LoggerConfig{
+		Level:          "info",
+		OutputPath:     []string{logFile},
+		Encoder:        "json",
+		RedactPatterns: []string{`sk-[A-Za-z0-9]{16,}`},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	logger.Error("request failed: invalid key sk-abcdef0123456789XYZ",
+		zap.String("detail", "token=sk-0000111122223333"), zap.String("user", "alice"))
+
+	data, err := SyncAndRead(logger, logFile)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	content := string(data)
+	if strings.Contains(content, "sk-") {
+		t.Errorf("api key not redacted: %s", content)
+	}
+	if !strings.Contains(content, "invalid key ***") || !ContainsField(data, "detail", "token=***") {
+		t.Errorf("expected redacted placeholders: %s", content)
+	}
+	if !ContainsField(data, "user", "alice") {
+		t.Errorf("unrelated fields should be kept: %s", content)
+	}
+
+	// 无效正则返回错误
+	if _, err := NewZapLogger(LoggerConfig{OutputPath: []string{"stdout"}, RedactPatterns: []string{"("}}); err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+}
+
+// credentials 测试用的对象字段
+type credentials struct {
+	User     string
+	Password string
+}
+
+func (c credentials) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("user", c.User)
+	enc.AddString("password", c.Password)
+	return nil
+}
+
+// TestRedactKeys 测试按字段名脱敏
+func TestRedactKeys(t *testing.T) {
+	// 确保日志目录存在
+	os.MkdirAll("./log", 0755)
+	logFile := "./log/redact_keys.log"
+	os.Remove(logFile)
+
+	logger, err := NewZapLogger(LoggerConfig{
+		Level:         "info",
+		OutputPath:    []string{logFile},
+		Encoder:       "json",
+		EncoderConfig: &EncoderConfig{RedactKeys: []string{"password", "Token"}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	logger.With(zap.String("token", "t-123")).Info("login",
+		zap.String("user", "alice"),
+		zap.String("password", "p@ss"),
+		zap.Object("creds", credentials{User: "bob", Password: "secret"}),
+		zap.Any("headers", map[string]string{"Token": "h-456", "accept": "json"}),
+		zap.Namespace("req"),
+		zap.String("password", "nested"),
+	)
+
+	data, err := SyncAndRead(logger, logFile)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	content := string(data)
+	for _, secret := range []string{"t-123", "p@ss", "secret", "h-456", "nested"} {
+		if strings.Contains(content, secret) {
+			t.Errorf("secret %q not redacted: %s", secret, content)
+		}
+	}
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatalf("Failed to parse log line: %v", err)
+	}
+	creds, _ := entry["creds"].(map[string]interface{})
+	headers, _ := entry["headers"].(map[string]interface{})
+	req, _ := entry["req"].(map[string]interface{})
+	if entry["password"] != "***" || entry["token"] != "***" || entry["user"] != "alice" {
+		t.Errorf("unexpected top-level fields: %s", content)
+	}
+	if creds["password"] != "***" || creds["user"] != "bob" {
+		t.Errorf("unexpected object fields: %v", creds)
+	}
+	if headers["Token"] != "***" || headers["accept"] != "json" {
+		t.Errorf("unexpected map fields: %v", headers)
+	}
+	if req["password"] != "***" {
+		t.Errorf("unexpected namespaced fields: %v", req)
+	}
+}
+
+// TestRedactKeysLevelFiltering 测试按字段名脱敏时各输出仍按自身级别过滤
+func TestRedactKeysLevelFiltering(t *testing.T) {
+	dir := t.TempDir()
+	encoderConfig := &EncoderConfig{RedactKeys: []string{"password"}}
+
+	t.Run("LevelSinks", func(t *testing.T) {
+		mainFile := filepath.Join(dir, "main.log")
+		errorFile := filepath.Join(dir, "error.log")
+		logger, err := NewZapLogger(LoggerConfig{
+			Level:         "info",
+			Encoder:       "json",
+			OutputPath:    []string{mainFile},
+			EncoderConfig: encoderConfig,
+			LevelSinks:    []LevelSink{{MinLevel: "error", OutputPath: []string{errorFile}}},
+		})
+		if err != nil {
+			t.Fatalf("Failed to create logger: %v", err)
+		}
+		defer logger.Close()
+
+		logger.Info("info line", zap.String("password", "p1"))
+		logger.Error("error line", zap.String("password", "p2"))
+
+		mainData, _ := SyncAndRead(logger, mainFile)
+		errorData, _ := os.ReadFile(errorFile)
+		if strings.Contains(string(errorData), "info line") || !strings.Contains(string(errorData), "error line") {
+			t.Errorf("error sink should contain only the error line: %s", errorData)
+		}
+		if !strings.Contains(string(mainData), "info line") || strings.Contains(string(mainData)+string(errorData), "p1") ||
+			strings.Contains(string(errorData), "p2") {
+			t.Errorf("passwords should be redacted in all outputs: %s%s", mainData, errorData)
+		}
+	})
+
+	t.Run("console-split", func(t *testing.T) {
+		stdout, _ := os.Create(filepath.Join(dir, "stdout"))
+		stderr, _ := os.Create(filepath.Join(dir, "stderr"))
+		origStdout, origStderr := os.Stdout, os.Stderr
+		os.Stdout, os.Stderr = stdout, stderr
+		defer func() { os.Stdout, os.Stderr = origStdout, origStderr }()
+
+		logger, err := NewRotatingLogger(RotateConfig{
+			Level:         "info",
+			Encoder:       "json",
+			OutputType:    "console-split",
+			Filename:      filepath.Join(dir, "app.log"),
+			EncoderConfig: encoderConfig,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create logger: %v", err)
+		}
+		logger.Info("info line", zap.String("password", "p1"))
+		logger.Error("error line", zap.String("password", "p2"))
+		logger.Close()
+
+		outData, _ := os.ReadFile(stdout.Name())
+		errData, _ := os.ReadFile(stderr.Name())
+		if !strings.Contains(string(outData), "info line") || strings.Contains(string(outData), "error line") {
+			t.Errorf("stdout should contain only the info line: %s", outData)
+		}
+		if !strings.Contains(string(errData), "error line") || strings.Contains(string(errData), "info line") {
+			t.Errorf("stderr should contain only the error line: %s", errData)
+		}
+		if strings.Contains(string(outData)+string(errData), `"p1"`) || strings.Contains(string(outData)+string(errData), `"p2"`) {
+			t.Errorf("passwords should be redacted: %s%s", outData, errData)
+		}
+	})
+}
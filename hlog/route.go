@@ -0,0 +1,210 @@
+package hlog
+
+import (
+	"container/list"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/calmu/hgotool/logrotate"
+	"go.uber.org/zap/zapcore"
+)
+
+// defaultRouteMaxOpen 按字段值路由时默认同时打开的最大文件数
+const defaultRouteMaxOpen = 64
+
+// routeEntry LRU中的一个路由文件
+type routeEntry struct {
+	route  string
+	writer *logrotate.RotateWriter
+}
+
+// routeWriters 按路由值管理轮转文件, 超过maxOpen时关闭最久未使用的文件
+type routeWriters struct {
+	mu      sync.Mutex
+	config  logrotate.RotateConfig
+	maxOpen int
+	lru     *list.List
+	items   map[string]*list.Element
+}
+
+// newRouteWriters 创建路由文件管理器, 并打开默认文件以尽早暴露配置错误
+func newRouteWriters(config logrotate.RotateConfig, maxOpen int) (*routeWriters, error) {
+	if maxOpen <= 0 {
+		maxOpen = defaultRouteMaxOpen
+	}
+	rw := &routeWriters{
+		config:  config,
+		maxOpen: maxOpen,
+		lru:     list.New(),
+		items:   make(map[string]*list.Element),
+	}
+	if _, err := rw.get(""); err != nil {
+		return nil, err
+	}
+	return rw, nil
+}
+
+// routeFilename 生成路由值对应的文件名, 如 app.log + a -> app_a.log, 空路由使用基础文件名
+func (rw *routeWriters) routeFilename(route string) string {
+	if route == "" {
+		return rw.config.Filename
+	}
+	// 避免路由值中的路径分隔符逃逸出日志目录
+	route = strings.NewReplacer("/", "_", "\\", "_", "..", "_").Replace(route)
+	ext := filepath.Ext(rw.config.Filename)
+	return strings.TrimSuffix(rw.config.Filename, ext) + "_" + route + ext
+}
+
+// get 获取路由值对应的写入器, 调用方需持有rw.mu
+func (rw *routeWriters) get(route string) (*logrotate.RotateWriter, error) {
+	if elem, ok := rw.items[route]; ok {
+		rw.lru.MoveToFront(elem)
+		return elem.Value.(*routeEntry).writer, nil
+	}
+
+	config := rw.config
+	config.Filename = rw.routeFilename(route)
+	writer, err := logrotate.NewRotateWriter(config)
+	if err != nil {
+		return nil, err
+	}
+	rw.items[route] = rw.lru.PushFront(&routeEntry{route: route, writer: writer})
+
+	for rw.lru.Len() > rw.maxOpen {
+		oldest := rw.lru.Back()
+		entry := oldest.Value.(*routeEntry)
+		entry.writer.Close()
+		rw.lru.Remove(oldest)
+		delete(rw.items, entry.route)
+	}
+	return writer, nil
+}
+
+// write 写入路由值对应的文件
+func (rw *routeWriters) write(route string, p []byte) error {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	writer, err := rw.get(route)
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(p)
+	return err
+}
+
+// sync 同步所有打开的文件
+func (rw *routeWriters) sync() error {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	var firstErr error
+	for elem := rw.lru.Front(); elem != nil; elem = elem.Next() {
+		if err := elem.Value.(*routeEntry).writer.Sync(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// close 关闭所有打开的文件
+func (rw *routeWriters) close() error {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	var firstErr error
+	for elem := rw.lru.Front(); elem != nil; elem = elem.Next() {
+		if err := elem.Value.(*routeEntry).writer.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	rw.lru.Init()
+	rw.items = make(map[string]*list.Element)
+	return firstErr
+}
+
+// routeCore 按指定字段的值将日志写入不同文件的zapcore.Core
+type routeCore struct {
+	zapcore.LevelEnabler
+	enc     zapcore.Encoder
+	key     string
+	route   string // 通过With绑定的路由值
+	writers *routeWriters
+}
+
+// newRouteCore 创建按key字段路由的core
+func newRouteCore(enc zapcore.Encoder, enab zapcore.LevelEnabler, key string, writers *routeWriters) *routeCore {
+	return &routeCore{
+		LevelEnabler: enab,
+		enc:          enc,
+		key:          key,
+		writers:      writers,
+	}
+}
+
+// With 实现zapcore.Core接口
+func (c *routeCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.enc = c.enc.Clone()
+	for i := range fields {
+		fields[i].AddTo(clone.enc)
+	}
+	if route, ok := c.routeOf(fields); ok {
+		clone.route = route
+	}
+	return &clone
+}
+
+// Check 实现zapcore.Core接口
+func (c *routeCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write 实现zapcore.Core接口
+func (c *routeCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	route := c.route
+	if r, ok := c.routeOf(fields); ok {
+		route = r
+	}
+
+	buf, err := c.enc.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	err = c.writers.write(route, buf.Bytes())
+	buf.Free()
+	if err != nil {
+		return err
+	}
+	if ent.Level > zapcore.ErrorLevel {
+		// 与zap默认core一致, 可能导致进程退出的日志立即同步
+		return c.Sync()
+	}
+	return nil
+}
+
+// Sync 实现zapcore.Core接口
+func (c *routeCore) Sync() error {
+	return c.writers.sync()
+}
+
+// routeOf 从字段中查找路由值
+func (c *routeCore) routeOf(fields []zapcore.Field) (string, bool) {
+	for _, f := range fields {
+		if f.Key != c.key {
+			continue
+		}
+		if f.Type == zapcore.StringType {
+			return f.String, true
+		}
+		enc := zapcore.NewMapObjectEncoder()
+		f.AddTo(enc)
+		return fmt.Sprint(enc.Fields[c.key]), true
+	}
+	return "", false
+}
@@ -0,0 +1,61 @@
+package hlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// TestRouteByField 测试按字段值将日志写入不同的轮转文件
+func TestRouteByField(t *testing.T) {
+	// 确保日志目录存在
+	os.MkdirAll("./log", 0755)
+	os.RemoveAll("./log/routed")
+
+	logger, err := NewRotatingLogger(RotateConfig{
+		Level:        "info",
+		Encoder:      "json",
+		OutputType:   "file",
+		Filename:     "./log/routed/app.log",
+		TimeRotation: "daily",
+		RouteKey:     "tenant",
+		RouteMaxOpen: 2, // 三个文件交替写入, 验证LRU关闭后重新打开
+	})
+	if err != nil {
+		t.Fatalf("Failed to create routed logger: %v", err)
+	}
+
+	logger.Info("message for a", zap.String("tenant", "a"))
+	logger.Info("message for b", zap.String("tenant", "b"))
+	logger.Info("message without tenant")
+	logger.Info("second message for a", zap.String("tenant", "a"))
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	today := time.Now().Format("2006-01-02")
+	expected := map[string][]string{
+		"app_a_" + today + ".log": {"message for a", "second message for a"},
+		"app_b_" + today + ".log": {"message for b"},
+		"app_" + today + ".log":   {"message without tenant"},
+	}
+	for name, msgs := range expected {
+		data, err := os.ReadFile(filepath.Join("./log/routed", name))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", name, err)
+		}
+		content := string(data)
+		if lines := strings.Count(content, "\n"); lines != len(msgs) {
+			t.Errorf("%s: expected %d lines, got %d: %s", name, len(msgs), lines, content)
+		}
+		for _, msg := range msgs {
+			if !strings.Contains(content, `"msg":"`+msg+`"`) {
+				t.Errorf("%s: missing %q: %s", name, msg, content)
+			}
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package hlog
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// slogHandler 将log/slog的记录写入HLogger的slog.Handler实现
+type slogHandler struct {
+	logger HLogger
+	fields []zap.Field // WithAttrs/WithGroup累积的字段, 分组以zap.Namespace表示
+}
+
+// NewSlogHandler 创建写入HLogger的slog.Handler
+//
+// slog级别映射为: 低于Info为debug, 低于Warn为info, 低于Error为warn, 其余为error;
+// 分组映射为嵌套对象; 对NewZapLogger/NewRotatingLogger创建的logger, caller取自slog调用处
+func NewSlogHandler(l HLogger) slog.Handler {
+	return &slogHandler{logger: l}
+}
+
+// Enabled 实现slog.Handler接口
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	if zl, ok := h.logger.(*zapLogger); ok {
+		return zl.logger.Core().Enabled(slogToZapLevel(level))
+	}
+	return true
+}
+
+// Handle 实现slog.Handler接口
+func (h *slogHandler) Handle(_ context.Context, record slog.Record) error {
+	fields := make([]zap.Field, 0, len(h.fields)+record.NumAttrs())
+	fields = append(fields, h.fields...)
+	record.Attrs(func(attr slog.Attr) bool {
+		if f, ok := slogAttrToField(attr); ok {
+			fields = append(fields, f)
+		}
+		return true
+	})
+
+	level := slogToZapLevel(record.Level)
+	if zl, ok := h.logger.(*zapLogger); ok {
+		ent := zapcore.Entry{
+			LoggerName: zl.logger.Name(),
+			Level:      level,
+			Time:       record.Time,
+			Message:    record.Message,
+		}
+		if record.PC != 0 {
+			frame, _ := runtime.CallersFrames([]uintptr{record.PC}).Next()
+			ent.Caller = zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, true)
+			ent.Caller.Function = frame.Function
+		}
+		if ce := zl.logger.Core().Check(ent, nil); ce != nil {
+			ce.Write(fields...)
+		}
+		return nil
+	}
+
+	switch level {
+	case zapcore.DebugLevel:
+		h.logger.Debug(record.Message, fields...)
+	case zapcore.InfoLevel:
+		h.logger.Info(record.Message, fields...)
+	case zapcore.WarnLevel:
+		h.logger.Warn(record.Message, fields...)
+	default:
+		h.logger.Error(record.Message, fields...)
+	}
+	return nil
+}
+
+// WithAttrs 实现slog.Handler接口
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make([]zap.Field, len(h.fields), len(h.fields)+len(attrs))
+	copy(fields, h.fields)
+	for _, attr := range attrs {
+		if f, ok := slogAttrToField(attr); ok {
+			fields = append(fields, f)
+		}
+	}
+	return &slogHandler{logger: h.logger, fields: fields}
+}
+
+// WithGroup 实现slog.Handler接口
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	fields := make([]zap.Field, len(h.fields), len(h.fields)+1)
+	copy(fields, h.fields)
+	fields = append(fields, zap.Namespace(name))
+	return &slogHandler{logger: h.logger, fields: fields}
+}
+
+// slogToZapLevel 将slog级别映射为zap级别
+func slogToZapLevel(level slog.Level) zapcore.Level {
+	switch {
+	case level < slog.LevelInfo:
+		return zapcore.DebugLevel
+	case level < slog.LevelWarn:
+		return zapcore.InfoLevel
+	case level < slog.LevelError:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.ErrorLevel
+	}
+}
+
+// slogAttrToField 将slog属性转换为zap字段, 空属性返回false
+func slogAttrToField(attr slog.Attr) (zap.Field, bool) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return zap.Skip(), false
+	}
+
+	switch attr.Value.Kind() {
+	case slog.KindString:
+		return zap.String(attr.Key, attr.Value.String()), true
+	case slog.KindInt64:
+		return zap.Int64(attr.Key, attr.Value.Int64()), true
+	case slog.KindUint64:
+		return zap.Uint64(attr.Key, attr.Value.Uint64()), true
+	case slog.KindFloat64:
+		return zap.Float64(attr.Key, attr.Value.Float64()), true
+	case slog.KindBool:
+		return zap.Bool(attr.Key, attr.Value.Bool()), true
+	case slog.KindDuration:
+		return zap.Duration(attr.Key, attr.Value.Duration()), true
+	case slog.KindTime:
+		return zap.Time(attr.Key, attr.Value.Time()), true
+	case slog.KindGroup:
+		group := attr.Value.Group()
+		if len(group) == 0 {
+			return zap.Skip(), false
+		}
+		// 空键的分组按slog约定内联到上一层
+		if attr.Key == "" {
+			return zap.Inline(slogGroup(group)), true
+		}
+		return zap.Object(attr.Key, slogGroup(group)), true
+	default:
+		return zap.Any(attr.Key, attr.Value.Any()), true
+	}
+}
+
+// slogGroup 将slog分组编码为zap对象
+type slogGroup []slog.Attr
+
+// MarshalLogObject 实现zapcore.ObjectMarshaler接口
+func (g slogGroup) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, attr := range g {
+		if f, ok := slogAttrToField(attr); ok {
+			f.AddTo(enc)
+		}
+	}
+	return nil
+}
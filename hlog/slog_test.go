@@ -0,0 +1,56 @@
+package hlog
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestSlogHandler 测试slog记录经由HLogger输出
+func TestSlogHandler(t *testing.T) {
+	// 确保日志目录存在
+	os.MkdirAll("./log", 0755)
+	logFile := "./log/slog_handler.log"
+	os.Remove(logFile)
+
+	logger, err := NewZapLogger(LoggerConfig{
+		Level:      "info",
+		OutputPath: []string{logFile},
+		Encoder:    "json",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	sl := slog.New(NewSlogHandler(logger))
+	sl.With("service", "api").WithGroup("req").Warn("slog message", "id", 7, slog.Group("user", "name", "alice"))
+	sl.Debug("suppressed debug")
+
+	data, err := SyncAndRead(logger, logFile)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 line, got %d: %s", len(lines), data)
+	}
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("Failed to parse log line: %v", err)
+	}
+	if entry["msg"] != "slog message" || entry["level"] != "warn" || entry["service"] != "api" {
+		t.Errorf("unexpected entry: %v", entry)
+	}
+	req, _ := entry["req"].(map[string]interface{})
+	user, _ := req["user"].(map[string]interface{})
+	if req["id"] != float64(7) || user["name"] != "alice" {
+		t.Errorf("unexpected group fields: %v", entry["req"])
+	}
+	if caller, _ := entry["caller"].(string); !strings.HasPrefix(caller, "hlog/slog_test.go:") {
+		t.Errorf("expected caller at slog call site, got %v", entry["caller"])
+	}
+}
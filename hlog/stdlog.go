@@ -0,0 +1,72 @@
+package hlog
+
+import (
+	"bytes"
+	"io"
+	"log"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// StdLogger 返回一个标准库 *log.Logger, 其输出按指定级别写入HLogger
+//
+// 用于接入只接受 *log.Logger 的第三方库, 未知级别按info处理
+func StdLogger(l HLogger, level string) *log.Logger {
+	lvl, err := zapcore.ParseLevel(level)
+	if err != nil {
+		lvl = zapcore.InfoLevel
+	}
+
+	if zl, ok := l.(*zapLogger); ok {
+		// zapLogger内部带有AddCallerSkip(1+CallerSkip), 此处抵消以便caller指向标准库调用方
+		stdLogger, err := zap.NewStdLogAt(zl.logger.WithOptions(zap.AddCallerSkip(-1-zl.callerSkip)), lvl)
+		if err == nil {
+			return stdLogger
+		}
+	}
+
+	return log.New(&stdLogWriter{logger: l, level: lvl}, "", 0)
+}
+
+// NewStdLogWriter 返回将写入内容按行转为HLogger日志的io.Writer, 可作为log.New或第三方库的输出
+//
+// 每行去掉行尾换行后输出为一条level级别的日志, 空行被忽略; 未知级别按info处理
+func NewStdLogWriter(l HLogger, level string) io.Writer {
+	lvl, err := zapcore.ParseLevel(level)
+	if err != nil {
+		lvl = zapcore.InfoLevel
+	}
+	return &stdLogWriter{logger: l, level: lvl}
+}
+
+// stdLogWriter 将写入内容的每一行转为HLogger的一条日志
+type stdLogWriter struct {
+	logger HLogger
+	level  zapcore.Level
+}
+
+// Write 实现io.Writer接口
+func (w *stdLogWriter) Write(p []byte) (int, error) {
+	for _, line := range bytes.Split(p, []byte("\n")) {
+		line = bytes.TrimSuffix(line, []byte("\r"))
+		if len(line) > 0 {
+			w.log(string(line))
+		}
+	}
+	return len(p), nil
+}
+
+// log 按配置的级别输出一条日志
+func (w *stdLogWriter) log(msg string) {
+	switch w.level {
+	case zapcore.DebugLevel:
+		w.logger.Debug(msg)
+	case zapcore.WarnLevel:
+		w.logger.Warn(msg)
+	case zapcore.InfoLevel:
+		w.logger.Info(msg)
+	default:
+		w.logger.Error(msg)
+	}
+}
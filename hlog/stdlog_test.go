@@ -0,0 +1,84 @@
+package hlog
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestStdLogger 测试标准库log.Logger输出被写入HLogger
+func TestStdLogger(t *testing.T) {
+	// 确保日志目录存在
+	os.MkdirAll("./log", 0755)
+	logFile := "./log/std_logger_test.log"
+	os.Remove(logFile)
+
+	logger, err := NewZapLogger(LoggerConfig{
+		Level:      "info",
+		OutputPath: []string{logFile},
+		Encoder:    "json",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	stdLogger := StdLogger(logger, "warn")
+	stdLogger.Print("message from std log")
+	logger.Close()
+
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatalf("Failed to parse log line %q: %v", data, err)
+	}
+	if entry["msg"] != "message from std log" {
+		t.Errorf("expected msg from std log, got %v", entry["msg"])
+	}
+	if entry["level"] != "warn" {
+		t.Errorf("expected level warn, got %v", entry["level"])
+	}
+}
+
+// TestNewStdLogWriter 测试io.Writer适配器按行输出日志
+func TestNewStdLogWriter(t *testing.T) {
+	// 确保日志目录存在
+	os.MkdirAll("./log", 0755)
+	logFile := "./log/std_log_writer_test.log"
+	os.Remove(logFile)
+
+	logger, err := NewZapLogger(LoggerConfig{
+		Level:      "info",
+		OutputPath: []string{logFile},
+		Encoder:    "json",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	writer := NewStdLogWriter(logger, "error")
+	log.New(writer, "", 0).Println("third-party failure")
+	writer.Write([]byte("\n"))
+	writer.Write(nil)
+	writer.Write([]byte("first\r\nsecond\n"))
+
+	data, err := SyncAndRead(logger, logFile)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %d: %s", len(lines), data)
+	}
+	for i, msg := range []string{"third-party failure", "first", "second"} {
+		if !ContainsField([]byte(lines[i]), "msg", msg) || !ContainsField([]byte(lines[i]), "level", "error") {
+			t.Errorf("unexpected line %d: %s", i, lines[i])
+		}
+	}
+}
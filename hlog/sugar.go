@@ -0,0 +1,46 @@
+package hlog
+
+import (
+	"go.uber.org/zap"
+)
+
+// sugaredLogger 基于zap.SugaredLogger的HSugaredLogger实现
+type sugaredLogger struct {
+	sugar *zap.SugaredLogger
+	owner *zapLogger
+}
+
+// Sugared 返回printf风格的日志视图, 与zl共享输出和日志级别
+func (zl *zapLogger) Sugared() HSugaredLogger {
+	return &sugaredLogger{sugar: zl.logger.Sugar(), owner: zl}
+}
+
+// Debugf 实现Debugf方法
+func (s *sugaredLogger) Debugf(template string, args ...interface{}) {
+	s.sugar.Debugf(template, args...)
+}
+
+// Infof 实现Infof方法
+func (s *sugaredLogger) Infof(template string, args ...interface{}) {
+	s.sugar.Infof(template, args...)
+}
+
+// Warnf 实现Warnf方法
+func (s *sugaredLogger) Warnf(template string, args ...interface{}) {
+	s.sugar.Warnf(template, args...)
+}
+
+// Errorf 实现Errorf方法
+func (s *sugaredLogger) Errorf(template string, args ...interface{}) {
+	s.sugar.Errorf(template, args...)
+}
+
+// Sync 刷新缓冲的日志
+func (s *sugaredLogger) Sync() error {
+	return s.sugar.Sync()
+}
+
+// Close 关闭所属的logger, 与直接调用HLogger的Close效果相同
+func (s *sugaredLogger) Close() error {
+	return s.owner.Close()
+}
@@ -0,0 +1,52 @@
+package hlog
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/calmu/hgotool/logrotate"
+)
+
+// TestSugared 测试printf风格日志与结构化日志共享输出和级别
+func TestSugared(t *testing.T) {
+	// 确保日志目录存在
+	os.MkdirAll("./log", 0755)
+	os.RemoveAll("./log/sugared")
+
+	logger, err := NewRotatingLogger(RotateConfig{
+		Level:          "info",
+		Encoder:        "json",
+		OutputType:     "file",
+		Filename:       "./log/sugared/app.log",
+		AsyncQueueSize: 16,
+		AsyncOverflow:  logrotate.OverflowBlock,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	sugar := logger.Sugared()
+	sugar.Infof("user %s did %s", "alice", "login")
+	sugar.Debugf("suppressed %d", 1)
+	sugar.Errorf("failed after %d retries", 3)
+	// 只通过sugared视图关闭, 异步队列中的日志也应被刷新
+	if err := sugar.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	data, err := os.ReadFile("./log/sugared/app.log")
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %s", len(lines), data)
+	}
+	if !ContainsField([]byte(lines[0]), "msg", "user alice did login") || !ContainsField([]byte(lines[0]), "caller", "hlog/sugar_test.go:30") {
+		t.Errorf("unexpected info line: %s", lines[0])
+	}
+	if !ContainsField([]byte(lines[1]), "level", "error") {
+		t.Errorf("unexpected error line: %s", lines[1])
+	}
+}
@@ -0,0 +1,63 @@
+package hlog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// ObservedLogs NewObservedLogger记录的日志, 可按消息、字段过滤并检查级别和字段
+type ObservedLogs = observer.ObservedLogs
+
+// NewObservedLogger 创建将日志记录在内存中的HLogger, 是测试通过HLogger记录日志的代码的推荐方式
+//
+// 记录所有级别的日志, 可通过返回的logger的SetLevel调整; 无需写文件后等待再读取, 例如:
+//
+//	logger, logs := hlog.NewObservedLogger()
+//	doWork(logger)
+//	entries := logs.FilterMessage("done").All()
+//	if len(entries) != 1 || entries[0].ContextMap()["count"] != int64(3) { ... }
+func NewObservedLogger() (HLogger, *ObservedLogs) {
+	level := zap.NewAtomicLevelAt(zapcore.DebugLevel)
+	core, logs := observer.New(level)
+	return &zapLogger{
+		logger: zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1)),
+		level:  level,
+	}, logs
+}
+
+// SyncAndRead 刷新logger后读取日志文件内容, 用于测试中替代 sleep 后读取文件的写法
+func SyncAndRead(logger HLogger, path string) ([]byte, error) {
+	// 标准输出等不支持Sync的输出会返回错误, 不影响文件内容
+	_ = logger.Sync()
+	return os.ReadFile(path)
+}
+
+// ContainsField 判断日志内容中是否有一行包含 key=value 的字段
+//
+// 支持json编码的整行以及console编码行尾的json字段, 值按 fmt.Sprint 的结果比较
+func ContainsField(data []byte, key, value string) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		idx := bytes.IndexByte(line, '{')
+		if idx < 0 {
+			continue
+		}
+		var fields map[string]interface{}
+		if err := json.Unmarshal(line[idx:], &fields); err != nil {
+			continue
+		}
+		if v, ok := fields[key]; ok && fmt.Sprint(v) == value {
+			return true
+		}
+	}
+	return false
+}
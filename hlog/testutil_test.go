@@ -0,0 +1,74 @@
+package hlog
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// TestSyncAndRead 测试记录日志后无需等待即可读取到字段
+func TestSyncAndRead(t *testing.T) {
+	// 确保日志目录存在
+	os.MkdirAll("./log", 0755)
+
+	for _, encoder := range []string{"json", "console"} {
+		logFile := "./log/sync_and_read_" + encoder + ".log"
+		os.Remove(logFile)
+
+		logger, err := NewZapLogger(LoggerConfig{
+			Level:      "info",
+			OutputPath: []string{logFile},
+			Encoder:    encoder,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create logger: %v", err)
+		}
+
+		logger.Info("sync and read", zap.String("request_id", "abc123"), zap.Int("code", 200))
+
+		data, err := SyncAndRead(logger, logFile)
+		if err != nil {
+			t.Fatalf("SyncAndRead failed: %v", err)
+		}
+		if !ContainsField(data, "request_id", "abc123") || !ContainsField(data, "code", "200") {
+			t.Errorf("%s: expected fields not found: %s", encoder, data)
+		}
+		if ContainsField(data, "request_id", "other") {
+			t.Errorf("%s: unexpected match for wrong value", encoder)
+		}
+		logger.Close()
+	}
+}
+
+// TestNewObservedLogger 测试在内存中检查记录的级别和字段
+func TestNewObservedLogger(t *testing.T) {
+	logger, logs := NewObservedLogger()
+
+	logger.Debug("debug message")
+	logger.With(zap.String("service", "api")).Warn("slow request", zap.Int("ms", 1200))
+
+	if logs.Len() != 2 {
+		t.Fatalf("expected 2 entries, got %d", logs.Len())
+	}
+	entries := logs.FilterMessage("slow request").All()
+	if len(entries) != 1 || entries[0].Level != zapcore.WarnLevel {
+		t.Fatalf("unexpected entries: %v", entries)
+	}
+	fields := entries[0].ContextMap()
+	if fields["service"] != "api" || fields["ms"] != int64(1200) {
+		t.Errorf("unexpected fields: %v", fields)
+	}
+	if caller := entries[0].Caller.File; !strings.HasSuffix(caller, "testutil_test.go") {
+		t.Errorf("expected caller in test file, got %s", caller)
+	}
+
+	// 级别可动态调整
+	logger.(HLevelSetter).SetLevel("error")
+	logger.Info("filtered")
+	if logs.FilterMessage("filtered").Len() != 0 {
+		t.Error("expected info to be filtered after SetLevel")
+	}
+}
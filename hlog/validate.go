@@ -0,0 +1,65 @@
+package hlog
+
+import (
+	"errors"
+	"fmt"
+)
+
+// validLevels 支持的日志级别, 空字符串按info处理
+var validLevels = map[string]bool{
+	"": true, "debug": true, "info": true, "warn": true, "error": true, "dpanic": true, "panic": true, "fatal": true,
+}
+
+// validEncoders 支持的编码器, 空字符串按console处理
+var validEncoders = map[string]bool{"": true, "json": true, "console": true}
+
+// validOutputTypes 轮转logger支持的输出类型
+var validOutputTypes = map[string]bool{"file": true, "stdout": true, "both": true, "console-split": true}
+
+// validRotateStyles 支持的文件命名方式, 空字符串按timestamp处理
+var validRotateStyles = map[string]bool{"": true, "timestamp": true, "rename": true}
+
+// validTimeRotations 支持的时间轮转周期, 空字符串表示只按大小轮转
+var validTimeRotations = map[string]bool{
+	"": true, "monthly": true, "weekly": true, "daily": true, "hourly": true, "minutely": true,
+}
+
+// Validate 检查配置中的枚举值, 返回所有无效字段的错误
+//
+// 未知的Level会按info处理、未知的Encoder会按console处理, 拼写错误不易发现, 可在启动时调用或设置Strict
+func (c LoggerConfig) Validate() error {
+	var errs []error
+	if !validLevels[c.Level] {
+		errs = append(errs, fmt.Errorf("invalid Level %q", c.Level))
+	}
+	if !validEncoders[c.Encoder] {
+		errs = append(errs, fmt.Errorf("invalid Encoder %q", c.Encoder))
+	}
+	return errors.Join(errs...)
+}
+
+// Validate 检查配置中的枚举值和文件输出的文件名, 返回所有无效字段的错误
+//
+// 未知的OutputType不会输出任何日志, 可在启动时调用或设置Strict
+func (c RotateConfig) Validate() error {
+	var errs []error
+	if !validLevels[c.Level] {
+		errs = append(errs, fmt.Errorf("invalid Level %q", c.Level))
+	}
+	if !validEncoders[c.Encoder] {
+		errs = append(errs, fmt.Errorf("invalid Encoder %q", c.Encoder))
+	}
+	if !validOutputTypes[c.OutputType] {
+		errs = append(errs, fmt.Errorf("invalid OutputType %q", c.OutputType))
+	}
+	if !validTimeRotations[c.TimeRotation] {
+		errs = append(errs, fmt.Errorf("invalid TimeRotation %q", c.TimeRotation))
+	}
+	if !validRotateStyles[c.RotateStyle] {
+		errs = append(errs, fmt.Errorf("invalid RotateStyle %q", c.RotateStyle))
+	}
+	if c.writesFile() && c.Filename == "" {
+		errs = append(errs, errors.New("empty Filename for file output"))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,80 @@
+package hlog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoggerConfigValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		config LoggerConfig
+		want   string // 期望错误包含的内容, 为空表示有效
+	}{
+		{"valid", LoggerConfig{Level: "debug", Encoder: "json"}, ""},
+		{"defaults", LoggerConfig{}, ""},
+		{"level", LoggerConfig{Level: "verbose", Encoder: "json"}, `invalid Level "verbose"`},
+		{"encoder", LoggerConfig{Level: "info", Encoder: "jsn"}, `invalid Encoder "jsn"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkValidateErr(t, tt.config.Validate(), tt.want)
+		})
+	}
+}
+
+func TestRotateConfigValidate(t *testing.T) {
+	valid := RotateConfig{Level: "info", Encoder: "json", OutputType: "both", TimeRotation: "daily", Filename: "app.log"}
+	tests := []struct {
+		name     string
+		override func(c *RotateConfig)
+		want     string
+	}{
+		{"valid", func(c *RotateConfig) {}, ""},
+		{"stdout without filename", func(c *RotateConfig) { c.OutputType, c.Filename = "stdout", "" }, ""},
+		{"level", func(c *RotateConfig) { c.Level = "Info" }, `invalid Level "Info"`},
+		{"encoder", func(c *RotateConfig) { c.Encoder = "jsn" }, `invalid Encoder "jsn"`},
+		{"output type", func(c *RotateConfig) { c.OutputType = "files" }, `invalid OutputType "files"`},
+		{"empty output type", func(c *RotateConfig) { c.OutputType = "" }, `invalid OutputType ""`},
+		{"time rotation", func(c *RotateConfig) { c.TimeRotation = "day" }, `invalid TimeRotation "day"`},
+		{"rotate style", func(c *RotateConfig) { c.RotateStyle = "move" }, `invalid RotateStyle "move"`},
+		{"filename", func(c *RotateConfig) { c.Filename = "" }, "empty Filename"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := valid
+			tt.override(&config)
+			checkValidateErr(t, config.Validate(), tt.want)
+		})
+	}
+}
+
+func TestStrictConfig(t *testing.T) {
+	if _, err := NewZapLogger(LoggerConfig{Encoder: "jsn", OutputPath: []string{"stdout"}, Strict: true}); err == nil {
+		t.Error("expected NewZapLogger to reject invalid config in strict mode")
+	}
+	if _, err := NewRotatingLogger(RotateConfig{OutputType: "file", Strict: true}); err == nil {
+		t.Error("expected NewRotatingLogger to reject invalid config in strict mode")
+	}
+
+	// 非严格模式保持原有的默认值处理
+	logger, err := NewZapLogger(LoggerConfig{Encoder: "jsn", OutputPath: []string{"stdout"}})
+	if err != nil {
+		t.Fatalf("non-strict NewZapLogger failed: %v", err)
+	}
+	logger.Close()
+}
+
+// checkValidateErr 检查err是否符合期望, want为空表示不应返回错误
+func checkValidateErr(t *testing.T, err error, want string) {
+	t.Helper()
+	if want == "" {
+		if err != nil {
+			t.Errorf("expected valid config, got %v", err)
+		}
+		return
+	}
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("expected error containing %q, got %v", want, err)
+	}
+}
@@ -0,0 +1,42 @@
+package hreflect
+
+import (
+	"reflect"
+	"sync"
+)
+
+// fieldInfo 结构体字段的预解析信息
+type fieldInfo struct {
+	index     int    // 字段下标
+	key       string // json标签名，没有时为字段名
+	omitempty bool   // json标签是否带omitempty
+	embedded  bool   // 是否为需要提升字段的匿名结构体
+}
+
+// fieldCache 按结构体类型缓存字段信息，值为[]fieldInfo
+var fieldCache sync.Map
+
+// cachedFields 返回结构体类型t的字段信息，首次使用时解析并缓存，json标签为"-"的字段不包含在内
+func cachedFields(t reflect.Type) []fieldInfo {
+	if fields, ok := fieldCache.Load(t); ok {
+		return fields.([]fieldInfo)
+	}
+
+	fields := make([]fieldInfo, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		key, omitempty := jsonKey(sf)
+		if key == "-" {
+			continue
+		}
+		fields = append(fields, fieldInfo{
+			index:     i,
+			key:       key,
+			omitempty: omitempty,
+			embedded:  isEmbeddedStruct(sf),
+		})
+	}
+
+	actual, _ := fieldCache.LoadOrStore(t, fields)
+	return actual.([]fieldInfo)
+}
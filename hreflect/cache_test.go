@@ -0,0 +1,91 @@
+package hreflect
+
+import (
+	"reflect"
+	"testing"
+)
+
+// benchStruct 20个字段的基准测试结构体
+type benchStruct struct {
+	F01 string  `json:"f01"`
+	F02 int     `json:"f02,omitempty"`
+	F03 bool    `json:"f03"`
+	F04 float64 `json:"f04"`
+	F05 string  `json:"f05"`
+	F06 int64   `json:"f06"`
+	F07 uint    `json:"f07"`
+	F08 string  `json:"f08,omitempty"`
+	F09 int     `json:"f09"`
+	F10 string  `json:"f10"`
+	F11 string  `json:"f11"`
+	F12 int     `json:"f12"`
+	F13 bool    `json:"f13"`
+	F14 float32 `json:"f14"`
+	F15 string  `json:"f15"`
+	F16 int32   `json:"f16"`
+	F17 string  `json:"-"`
+	F18 string  `json:"f18"`
+	F19 int     `json:"f19"`
+	F20 string
+}
+
+func TestCachedFields(t *testing.T) {
+	typ := reflect.TypeOf(benchStruct{})
+	fields := cachedFields(typ)
+	if len(fields) != 19 {
+		t.Fatalf("expected 19 fields without json:\"-\", got %d", len(fields))
+	}
+	if fields[1].key != "f02" || !fields[1].omitempty {
+		t.Errorf("unexpected field info: %+v", fields[1])
+	}
+	if fields[18].key != "F20" || fields[18].index != 19 {
+		t.Errorf("unexpected field info: %+v", fields[18])
+	}
+
+	// 再次获取返回同一份缓存
+	if again := cachedFields(typ); &again[0] != &fields[0] {
+		t.Error("expected cached field slice to be reused")
+	}
+}
+
+// BenchmarkStructToMap 对比缓存与每次重新解析字段的开销，可用 -benchtime=100000x 固定迭代次数
+func BenchmarkStructToMap(b *testing.B) {
+	obj := benchStruct{F01: "a", F02: 1, F03: true, F04: 1.5, F20: "z"}
+	typ := reflect.TypeOf(obj)
+
+	b.Run("cached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = StructToMap(obj)
+		}
+	})
+	b.Run("uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			fieldCache.Delete(typ)
+			_, _ = StructToMap(obj)
+		}
+	})
+}
+
+// BenchmarkMapToStruct 对比缓存与每次重新解析字段的开销，可用 -benchtime=100000x 固定迭代次数
+func BenchmarkMapToStruct(b *testing.B) {
+	data, _ := StructToMap(benchStruct{F01: "a", F02: 1, F03: true, F04: 1.5, F20: "z"})
+	typ := reflect.TypeOf(benchStruct{})
+
+	b.Run("cached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var obj benchStruct
+			_ = MapToStruct(data, &obj)
+		}
+	})
+	b.Run("uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			fieldCache.Delete(typ)
+			var obj benchStruct
+			_ = MapToStruct(data, &obj)
+		}
+	})
+}
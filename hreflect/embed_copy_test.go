@@ -47,3 +47,194 @@ func TestStructToStruct(t *testing.T) {
 
 	t.Log("StructToStruct success")
 }
+
+func TestEmbedCopyFields(t *testing.T) {
+	type a struct {
+		Name string
+		Age  int
+	}
+	type b struct {
+		Name string
+		Age  int
+	}
+
+	src := a{Name: "mm", Age: 18}
+	dst := b{Name: "old", Age: 30}
+
+	EmbedCopyFields(&dst, &src, "Name")
+
+	if dst.Name != "mm" {
+		t.Errorf("expected Name to be copied, got %q", dst.Name)
+	}
+	if dst.Age != 30 {
+		t.Errorf("expected Age to keep prior value 30, got %d", dst.Age)
+	}
+}
+
+func TestEmbedCopyDeep(t *testing.T) {
+	type inner struct {
+		Tags []string
+	}
+	type a struct {
+		Name  *string
+		Nums  []int
+		Attrs map[string]int
+		Inner inner
+		Ptr   *inner
+		Age   int
+	}
+	type b struct {
+		Name  *string
+		Nums  []int
+		Attrs map[string]int
+		Inner inner
+		Ptr   *inner
+		Age   string // 类型不同，跳过
+	}
+
+	name := "mm"
+	src := a{
+		Name:  &name,
+		Nums:  []int{1, 2},
+		Attrs: map[string]int{"k": 1},
+		Inner: inner{Tags: []string{"x"}},
+		Ptr:   &inner{Tags: []string{"y"}},
+		Age:   18,
+	}
+	dst := b{Age: "old"}
+
+	EmbedCopyDeep(&dst, &src)
+
+	*src.Name = "changed"
+	src.Nums[0] = 100
+	src.Attrs["k"] = 100
+	src.Inner.Tags[0] = "changed"
+	src.Ptr.Tags[0] = "changed"
+
+	if dst.Name == nil || *dst.Name != "mm" {
+		t.Errorf("expected Name to be independent, got %v", dst.Name)
+	}
+	if dst.Nums[0] != 1 || dst.Attrs["k"] != 1 {
+		t.Errorf("expected slice and map to be independent, got %v %v", dst.Nums, dst.Attrs)
+	}
+	if dst.Inner.Tags[0] != "x" || dst.Ptr.Tags[0] != "y" {
+		t.Errorf("expected nested struct to be independent, got %v %v", dst.Inner, dst.Ptr)
+	}
+	if dst.Age != "old" {
+		t.Errorf("expected Age to be skipped, got %q", dst.Age)
+	}
+
+	// nil字段保持nil
+	var empty b
+	EmbedCopyDeep(&empty, a{})
+	if empty.Name != nil || empty.Nums != nil || empty.Attrs != nil || empty.Ptr != nil {
+		t.Errorf("expected nil fields to stay nil, got %+v", empty)
+	}
+}
+
+func TestEmbedCopyConvertible(t *testing.T) {
+	type Status string
+	type a struct {
+		ID     int
+		Status string
+		Code   int
+		Tags   []string
+	}
+	type b struct {
+		ID     int64
+		Status Status
+		Code   string // int转string会按rune解释，跳过
+		Tags   [3]string
+	}
+
+	src := a{ID: 42, Status: "active", Code: 65, Tags: []string{"x"}}
+	dst := b{Code: "keep"}
+
+	EmbedCopy(&dst, &src)
+
+	if dst.ID != 42 {
+		t.Errorf("expected ID to be converted to 42, got %d", dst.ID)
+	}
+	if dst.Status != "active" {
+		t.Errorf("expected Status to be converted, got %q", dst.Status)
+	}
+	if dst.Code != "keep" {
+		t.Errorf("expected Code to be skipped, got %q", dst.Code)
+	}
+	if dst.Tags != [3]string{} {
+		t.Errorf("expected short slice to array to be skipped, got %v", dst.Tags)
+	}
+}
+
+func TestEmbedCopyNarrowing(t *testing.T) {
+	type a struct {
+		Small  int64
+		Large  int64
+		Whole  float64
+		Frac   float64
+		Neg    int
+		Big    uint
+		Huge   float64
+		Narrow float64
+	}
+	type b struct {
+		Small  int8
+		Large  int8    // 超出int8范围, 跳过
+		Whole  int     // 整数值的浮点数可以转换
+		Frac   int     // 带小数部分, 跳过
+		Neg    uint    // 负数, 跳过
+		Big    int     // 超出int范围, 跳过
+		Huge   float32 // 超出float32范围, 跳过
+		Narrow float32
+	}
+
+	src := a{Small: 100, Large: 300, Whole: 3, Frac: 1.5, Neg: -1, Big: ^uint(0), Huge: 1e40, Narrow: 0.5}
+	dst := b{Large: 7, Frac: 7, Neg: 7, Big: 7, Huge: 7}
+
+	EmbedCopy(&dst, &src)
+
+	want := b{Small: 100, Large: 7, Whole: 3, Frac: 7, Neg: 7, Big: 7, Huge: 7, Narrow: 0.5}
+	if dst != want {
+		t.Errorf("expected %+v, got %+v", want, dst)
+	}
+}
+
+func TestEmbedCopyInvalidInput(t *testing.T) {
+	type a struct {
+		Name string
+		Age  int
+	}
+	type b struct {
+		Name string
+		Age  int64
+		Note string
+	}
+
+	src := a{Name: "mm", Age: 18}
+	var dst b
+
+	copied, err := EmbedCopy(&dst, src)
+	if err != nil || copied != 2 {
+		t.Errorf("expected 2 fields copied, got %d, %v", copied, err)
+	}
+
+	var nilDst *b
+	cases := []struct {
+		name     string
+		dst, src interface{}
+	}{
+		{"nil dst", nil, src},
+		{"nil pointer dst", nilDst, src},
+		{"non-pointer dst", dst, src},
+		{"pointer to non-struct dst", new(int), src},
+		{"nil src", &dst, nil},
+		{"nil pointer src", &dst, (*a)(nil)},
+		{"non-struct src", &dst, 42},
+	}
+	for _, c := range cases {
+		copied, err := EmbedCopy(c.dst, c.src)
+		if err == nil || copied != 0 {
+			t.Errorf("%s: expected error, got %d, %v", c.name, copied, err)
+		}
+	}
+}
@@ -9,17 +9,67 @@
 package hreflect
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 )
 
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+)
+
+// mapOptions StructToMap和MapToStruct的可选配置
+type mapOptions struct {
+	formatTime bool
+	timeLayout string
+	deep       bool
+	strict     bool
+	keepEmpty  bool // 忽略omitempty, 供StructDiff比较所有字段
+}
+
+// MapOption StructToMap和MapToStruct的可选配置项
+type MapOption func(o *mapOptions)
+
+// WithTimeLayout 将time.Time和*time.Time字段按layout格式化为字符串，layout为空时使用time.RFC3339，nil指针输出nil
+//
+// 用于MapToStruct时，解析time.Time字段的字符串值优先使用该layout
+func WithTimeLayout(layout string) MapOption {
+	return func(o *mapOptions) {
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		o.formatTime = true
+		o.timeLayout = layout
+	}
+}
+
+// WithDeep 递归转换嵌套结构体: 结构体及结构体指针字段转换为map[string]interface{}，
+// 结构体或结构体指针的切片和数组转换为[]map[string]interface{}，nil指针输出nil，time.Time保持原值(可配合WithTimeLayout)
+func WithDeep() MapOption {
+	return func(o *mapOptions) {
+		o.deep = true
+	}
+}
+
+// WithStrict MapToStruct遇到无法转换的值时返回汇总的错误(包含字段名和原始值)，默认忽略失败的字段尽力转换
+func WithStrict() MapOption {
+	return func(o *mapOptions) {
+		o.strict = true
+	}
+}
+
 // EmbedCopy
 //
-//	@Description:
+//	@Description: 将src中的同名字段复制到dst，返回复制的字段数；dst必须是非nil的结构体指针，src必须是结构体或非nil的结构体指针
 //	@param dst interface{}
 //	@param src interface{}
+//	@return copied int
+//	@return err error
 //
 // ----------------develop info----------------
 //
@@ -27,70 +77,433 @@ import (
 //	@DateTime:		2024-08-04 19:42:55
 //
 // --------------------------------------------
-func EmbedCopy(dst, src interface{}) {
-	dv := reflect.ValueOf(dst).Elem()
-	sv := reflect.Indirect(reflect.ValueOf(src))
+func EmbedCopy(dst, src interface{}) (copied int, err error) {
+	return embedCopy(dst, src, nil, false)
+}
+
+// EmbedCopyDeep 与EmbedCopy规则相同，但对指针、切片、map和嵌套结构体做深拷贝，复制后dst与src不共享内存
+//
+// 不处理循环引用
+func EmbedCopyDeep(dst, src interface{}) (copied int, err error) {
+	return embedCopy(dst, src, nil, true)
+}
+
+// EmbedCopyFields 仅复制fields中列出的同名字段(按Go字段名匹配)，其余字段保持不变
+func EmbedCopyFields(dst, src interface{}, fields ...string) (copied int, err error) {
+	allow := make(map[string]struct{}, len(fields))
+	for _, name := range fields {
+		allow[name] = struct{}{}
+	}
+	return embedCopy(dst, src, allow, false)
+}
+
+// embedCopy 复制同名字段，类型不同但可转换(如int->int64、string->自定义string类型)时转换后复制，
+// allow不为nil时只复制其中的字段，deep为true时深拷贝
+func embedCopy(dst, src interface{}, allow map[string]struct{}, deep bool) (int, error) {
+	dv := reflect.ValueOf(dst)
+	if dv.Kind() != reflect.Ptr || dv.IsNil() || dv.Elem().Kind() != reflect.Struct {
+		return 0, fmt.Errorf("dst must be a non-nil pointer to struct, got %T", dst)
+	}
+	dv = dv.Elem()
+
+	sv := reflect.ValueOf(src)
+	if sv.Kind() == reflect.Ptr {
+		if sv.IsNil() {
+			return 0, fmt.Errorf("src must be a struct or non-nil pointer to struct, got nil %T", src)
+		}
+		sv = sv.Elem()
+	}
+	if sv.Kind() != reflect.Struct {
+		return 0, fmt.Errorf("src must be a struct or non-nil pointer to struct, got %T", src)
+	}
 
+	var copied int
 	for i := 0; i < sv.NumField(); i++ {
 		sf := sv.Type().Field(i)
+		if allow != nil {
+			if _, ok := allow[sf.Name]; !ok {
+				continue
+			}
+		}
 		// 找 dst 里同名字段
 		if df := dv.FieldByName(sf.Name); df.IsValid() && df.CanSet() {
+			value := sv.Field(i)
+			if deep {
+				value = deepCopy(value)
+			}
 			if df.Type() == sf.Type {
-				df.Set(sv.Field(i))
+				df.Set(value)
+				copied++
+			} else if canConvert(value, df.Type()) {
+				df.Set(value.Convert(df.Type()))
+				copied++
 			}
 		}
 	}
+	return copied, nil
+}
+
+// canConvert 判断v能否安全转换为类型t
+//
+// 排除整数转字符串(会按rune解释)、长度不足的切片转数组(会panic),
+// 以及超出目标类型范围或带小数部分的浮点数转整数等会被Convert静默截断的数值
+func canConvert(v reflect.Value, t reflect.Type) bool {
+	if !v.Type().ConvertibleTo(t) {
+		return false
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return t.Kind() != reflect.String && !numberOverflows(v, t)
+	case reflect.Slice:
+		switch {
+		case t.Kind() == reflect.Array:
+			return v.Len() >= t.Len()
+		case t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Array:
+			return v.Len() >= t.Elem().Len()
+		}
+	}
+	return true
+}
+
+// numberOverflows 判断数值v转换为类型t时是否超出范围或丢失浮点数的小数部分, t不是数值类型时返回false
+func numberOverflows(v reflect.Value, t reflect.Type) bool {
+	target := reflect.Zero(t)
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := toInt64(v)
+		return !ok || target.OverflowInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, ok := toUint64(v)
+		return !ok || target.OverflowUint(u)
+	case reflect.Float32, reflect.Float64:
+		switch v.Kind() {
+		case reflect.Float32, reflect.Float64:
+			return target.OverflowFloat(v.Float())
+		}
+	}
+	return false
+}
+
+// deepCopy 返回v的深拷贝，结构体的未导出字段按值复制
+func deepCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		out := reflect.New(v.Type().Elem())
+		out.Elem().Set(deepCopy(v.Elem()))
+		return out
+	case reflect.Interface:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		out := reflect.New(v.Type()).Elem()
+		out.Set(deepCopy(v.Elem()))
+		return out
+	case reflect.Slice:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		out := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return out
+	case reflect.Array:
+		out := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		out := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out.SetMapIndex(deepCopy(iter.Key()), deepCopy(iter.Value()))
+		}
+		return out
+	case reflect.Struct:
+		out := reflect.New(v.Type()).Elem()
+		out.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if f := out.Field(i); f.CanSet() {
+				f.Set(deepCopy(v.Field(i)))
+			}
+		}
+		return out
+	}
+	return v
 }
 
 // StructToMap 将结构体转换为map
-func StructToMap(obj interface{}) (map[string]interface{}, error) {
-	data := make(map[string]interface{})
+//
+// 与encoding/json一致，反射无法读取的未导出字段总是跳过；经由未导出的匿名结构体提升的导出字段仍会输出
+func StructToMap(obj interface{}, opts ...MapOption) (map[string]interface{}, error) {
+	var options mapOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	objValue, err := structValue(obj)
+	if err != nil {
+		return nil, err
+	}
+	return options.structToMap(objValue), nil
+}
+
+// MergeStructToMap 按与StructToMap相同的规则将结构体字段写入dst，dst中已有的同名键被覆盖
+//
+// 依次合并多个结构体时后合并的优先，可复用同一个map避免重复分配
+func MergeStructToMap(dst map[string]interface{}, obj interface{}, opts ...MapOption) error {
+	if dst == nil {
+		return fmt.Errorf("dst must be a non-nil map")
+	}
+	var options mapOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	objValue, err := structValue(obj)
+	if err != nil {
+		return err
+	}
+	options.fillMap(dst, objValue)
+	return nil
+}
+
+// StructDiff 比较两个同类型结构体(或结构体指针)，返回值不同的字段，键名规则与StructToMap相同，值为newObj中的值
+//
+// 嵌套结构体作为整体比较，不递归展开；未导出字段和json标签为"-"的字段不参与比较，omitempty不生效
+func StructDiff(oldObj, newObj interface{}) (map[string]interface{}, error) {
+	oldValue, err := structValue(oldObj)
+	if err != nil {
+		return nil, err
+	}
+	newValue, err := structValue(newObj)
+	if err != nil {
+		return nil, err
+	}
+	if oldValue.Type() != newValue.Type() {
+		return nil, fmt.Errorf("type mismatch: %s and %s", oldValue.Type(), newValue.Type())
+	}
+
+	options := mapOptions{keepEmpty: true}
+	oldMap, newMap := options.structToMap(oldValue), options.structToMap(newValue)
+	diff := make(map[string]interface{})
+	for key, value := range newMap {
+		if old, ok := oldMap[key]; !ok || !reflect.DeepEqual(old, value) {
+			diff[key] = value
+		}
+	}
+	// 只在旧值中存在的键(如新值中匿名结构体指针为nil)视为变为nil
+	for key := range oldMap {
+		if _, ok := newMap[key]; !ok {
+			diff[key] = nil
+		}
+	}
+	return diff, nil
+}
+
+// structValue 获取obj对应的结构体值，obj必须是结构体或结构体指针
+func structValue(obj interface{}) (reflect.Value, error) {
 	objValue := reflect.ValueOf(obj)
-	objType := reflect.TypeOf(obj)
 
 	// 如果是指针，获取其指向的元素
 	if objValue.Kind() == reflect.Ptr {
 		objValue = objValue.Elem()
-		objType = objType.Elem()
 	}
 
 	// 确保传入的是结构体
 	if objValue.Kind() != reflect.Struct {
-		return nil, fmt.Errorf("input must be a struct or pointer to struct")
+		return reflect.Value{}, fmt.Errorf("input must be a struct or pointer to struct")
 	}
+	return objValue, nil
+}
 
-	for i := 0; i < objValue.NumField(); i++ {
-		field := objValue.Field(i)
-		fieldType := objType.Field(i)
+// structToMap 按配置将结构体值转换为map
+func (o *mapOptions) structToMap(objValue reflect.Value) map[string]interface{} {
+	data := make(map[string]interface{})
+	o.fillMap(data, objValue)
+	return data
+}
 
-		// 获取json标签作为键名，如果没有则使用字段名
-		key := fieldType.Name
-		if jsonTag := fieldType.Tag.Get("json"); jsonTag != "" {
-			// 解析json标签，处理如 "name,omitempty" 的情况
-			if commaIdx := strings.Index(jsonTag, ","); commaIdx != -1 {
-				key = jsonTag[:commaIdx]
-			} else {
-				key = jsonTag
-			}
-			// 如果json标签为"-"，则跳过该字段
-			if key == "-" {
+// fillMap 按配置将结构体值的字段写入data，同名键覆盖data中已有的值
+func (o *mapOptions) fillMap(data map[string]interface{}, objValue reflect.Value) {
+	// 键名、omitempty等标签信息按类型缓存，json标签为"-"的字段已被排除
+	fields := cachedFields(objValue.Type())
+
+	// 未指定标签名的匿名结构体字段与encoding/json一致，将其字段提升到当前层，nil指针跳过；
+	// 逆序先写入提升的字段，使先出现的匿名字段以及外层字段依次覆盖同名键
+	for i := len(fields) - 1; i >= 0; i-- {
+		if !fields[i].embedded {
+			continue
+		}
+		field := objValue.Field(fields[i].index)
+		if field.Kind() == reflect.Ptr {
+			if field.IsNil() {
 				continue
 			}
+			field = field.Elem()
+		}
+		o.fillMap(data, field)
+	}
+
+	for _, info := range fields {
+		if info.embedded {
+			continue
+		}
+		field := objValue.Field(info.index)
+
+		// 带omitempty选项的零值字段按encoding/json的规则跳过
+		if info.omitempty && !o.keepEmpty && isEmptyValue(field) {
+			continue
 		}
 
-		// 如果字段是可导出的，添加到map中
+		// 未导出字段跳过
 		if field.CanInterface() {
-			data[key] = field.Interface()
+			data[info.key] = o.convert(field)
 		}
 	}
+}
 
-	return data, nil
+// isEmbeddedStruct 判断字段是否为需要提升的匿名结构体(或结构体指针)，带json标签名的匿名字段按普通字段处理
+func isEmbeddedStruct(field reflect.StructField) bool {
+	if !field.Anonymous {
+		return false
+	}
+	if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); name != "" {
+		return false
+	}
+	t := field.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
+// jsonKey 解析json标签，返回键名及是否带omitempty选项，处理如 "name,omitempty" 的情况
+//
+// 没有标签或标签名为空(如 ",omitempty")时使用字段名
+func jsonKey(field reflect.StructField) (string, bool) {
+	name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		name = field.Name
+	}
+	var omitempty bool
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		if opt == "omitempty" {
+			omitempty = true
+		}
+	}
+	return name, omitempty
+}
+
+// isEmptyValue 判断字段是否为omitempty意义下的零值，规则与encoding/json一致:
+// false、0、nil指针/接口，以及长度为0的字符串、切片、map和数组
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+// convert 按配置转换字段值
+func (o *mapOptions) convert(field reflect.Value) interface{} {
+	if o.formatTime {
+		switch {
+		case field.Type() == timeType:
+			return field.Interface().(time.Time).Format(o.timeLayout)
+		case field.Kind() == reflect.Ptr && field.Type().Elem() == timeType:
+			if field.IsNil() {
+				return nil
+			}
+			return field.Elem().Interface().(time.Time).Format(o.timeLayout)
+		}
+	}
+	if o.deep {
+		if v, ok := o.convertDeep(field); ok {
+			return v
+		}
+	}
+	return field.Interface()
+}
+
+// convertDeep 递归转换结构体、结构体指针和结构体切片，其他类型返回false
+func (o *mapOptions) convertDeep(field reflect.Value) (interface{}, bool) {
+	switch field.Kind() {
+	case reflect.Struct:
+		if field.Type() == timeType {
+			return nil, false
+		}
+		return o.structToMap(field), true
+	case reflect.Ptr:
+		if elem := field.Type().Elem(); elem.Kind() != reflect.Struct || elem == timeType {
+			return nil, false
+		}
+		if field.IsNil() {
+			return nil, true
+		}
+		return o.structToMap(field.Elem()), true
+	case reflect.Slice, reflect.Array:
+		if !isStructElem(field.Type().Elem()) {
+			return nil, false
+		}
+		if field.Kind() == reflect.Slice && field.IsNil() {
+			return []map[string]interface{}(nil), true
+		}
+		items := make([]map[string]interface{}, field.Len())
+		for i := range items {
+			item := field.Index(i)
+			if item.Kind() == reflect.Ptr {
+				if item.IsNil() {
+					continue
+				}
+				item = item.Elem()
+			}
+			items[i] = o.structToMap(item)
+		}
+		return items, true
+	}
+	return nil, false
+}
+
+// isStructElem 判断切片或数组元素是否为需要递归转换的结构体或结构体指针(time.Time除外)
+func isStructElem(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && t != timeType
 }
 
 // MapToStruct 将map转换为结构体
-func MapToStruct(data map[string]interface{}, obj interface{}) error {
+//
+// 默认忽略无法转换的值，对应字段保持原值；开启WithStrict后返回所有失败字段的汇总错误
+func MapToStruct(data map[string]interface{}, obj interface{}, opts ...MapOption) error {
+	var options mapOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	objValue := reflect.ValueOf(obj)
-	objType := reflect.TypeOf(obj)
 
 	// 确保是指针类型
 	if objValue.Kind() != reflect.Ptr {
@@ -98,50 +511,104 @@ func MapToStruct(data map[string]interface{}, obj interface{}) error {
 	}
 
 	objValue = objValue.Elem()
-	objType = objType.Elem()
 
 	// 确保指向的是结构体
 	if objValue.Kind() != reflect.Struct {
 		return fmt.Errorf("destination must be a pointer to struct")
 	}
 
-	for i := 0; i < objValue.NumField(); i++ {
-		field := objValue.Field(i)
-		fieldType := objType.Field(i)
+	if err := options.mapToStruct(data, objValue); err != nil && options.strict {
+		return err
+	}
+	return nil
+}
 
-		// 获取json标签作为键名，如果没有则使用字段名
-		key := fieldType.Name
-		if jsonTag := fieldType.Tag.Get("json"); jsonTag != "" {
-			// 解析json标签，处理如 "name,omitempty" 的情况
-			if commaIdx := strings.Index(jsonTag, ","); commaIdx != -1 {
-				key = jsonTag[:commaIdx]
-			} else {
-				key = jsonTag
-			}
-			// 如果json标签为"-"，则跳过该字段
-			if key == "-" {
-				continue
-			}
-		}
+// mapToStruct 将map写入结构体值，返回所有字段转换失败的汇总错误
+func (o *mapOptions) mapToStruct(data map[string]interface{}, objValue reflect.Value) error {
+	var errs []error
+
+	// 键名按类型缓存，json标签为"-"的字段已被排除
+	for _, info := range cachedFields(objValue.Type()) {
+		field := objValue.Field(info.index)
+		key := info.key
 
 		// 检查map中是否存在对应的键
 		if value, exists := data[key]; exists {
 			// 确保字段可设置
 			if field.CanSet() {
 				// 类型转换并设置值
-				setValue(field, value)
+				if err := o.setValue(field, value); err != nil {
+					errs = append(errs, fmt.Errorf("field %q: %w", key, err))
+				}
 			}
 		}
 	}
 
-	return nil
+	return errors.Join(errs...)
+}
+
+// isNumberKind 判断是否为整数或浮点类型
+func isNumberKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
 }
 
-// setValue 设置字段值，处理类型转换
-func setValue(field reflect.Value, value interface{}) {
+// toInt64 将数值转换为int64，浮点数必须为整数值且不超出范围
+func toInt64(rv reflect.Value) (int64, bool) {
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u := rv.Uint()
+		return int64(u), u <= math.MaxInt64
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+			return 0, false
+		}
+		return int64(f), true
+	}
+	return 0, false
+}
+
+// toUint64 将数值转换为uint64，负数和非整数浮点数视为失败
+func toUint64(rv reflect.Value) (uint64, bool) {
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i := rv.Int()
+		return uint64(i), i >= 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint(), true
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if f != math.Trunc(f) || f < 0 || f >= math.MaxUint64 {
+			return 0, false
+		}
+		return uint64(f), true
+	}
+	return 0, false
+}
+
+// setValue 设置字段值，处理类型转换，无法转换时返回错误且字段保持原值
+//
+// time.Time字段接受字符串和数值: 字符串依次尝试WithTimeLayout指定的layout、RFC3339、
+// "2006-01-02 15:04:05"和"2006-01-02"，数值按Unix秒解释(浮点数的小数部分为纳秒)
+//
+// 数值转换规则(切片/数组元素同样适用，如JSON解码得到的[]interface{}{float64...}):
+//   - 任意整数、浮点类型之间可互相转换，字符串按十进制解析
+//   - 浮点数写入整数字段时必须是整数值(如 3.0)，带小数部分的值不会被截断而是跳过
+//   - 超出目标类型范围的值(如 300 写入 int8，负数写入 uint，1e40 写入 float32)会被跳过
+//
+// 切片、数组和map中单个元素转换失败时该元素置零，错误中带上元素下标或键
+func (o *mapOptions) setValue(field reflect.Value, value interface{}) error {
 	// 如果值为nil，直接返回
 	if value == nil {
-		return
+		return nil
 	}
 
 	fieldType := field.Type()
@@ -150,7 +617,27 @@ func setValue(field reflect.Value, value interface{}) {
 	// 如果类型相同，直接设置
 	if fieldType == valueType {
 		field.Set(reflect.ValueOf(value))
-		return
+		return nil
+	}
+
+	// time.Duration 字段: 字符串按 time.ParseDuration 解析(如 "30s", "1h30m"),
+	// 数值与其他整数字段一致, 按纳秒解释
+	if fieldType == durationType {
+		if str, ok := value.(string); ok {
+			if d, err := time.ParseDuration(str); err == nil {
+				field.SetInt(int64(d))
+				return nil
+			}
+		}
+	}
+
+	if fieldType == timeType {
+		t, ok := o.parseTime(value)
+		if !ok {
+			return convertError(value, fieldType)
+		}
+		field.Set(reflect.ValueOf(t))
+		return nil
 	}
 
 	// 尝试类型转换
@@ -164,66 +651,49 @@ func setValue(field reflect.Value, value interface{}) {
 			field.SetString(fmt.Sprintf("%v", value))
 		}
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		switch v := value.(type) {
-		case int:
-			field.SetInt(int64(v))
-		case int8:
-			field.SetInt(int64(v))
-		case int16:
-			field.SetInt(int64(v))
-		case int32:
-			field.SetInt(int64(v))
-		case int64:
-			field.SetInt(v)
-		case string:
-			if i, err := strconv.ParseInt(v, 10, 64); err == nil {
-				field.SetInt(i)
-			}
-		case float64:
-			field.SetInt(int64(v))
-		case float32:
-			field.SetInt(int64(v))
+		var i int64
+		var ok bool
+		if str, isStr := value.(string); isStr {
+			var err error
+			i, err = strconv.ParseInt(str, 10, 64)
+			ok = err == nil
+		} else {
+			i, ok = toInt64(reflect.ValueOf(value))
+		}
+		if !ok || field.OverflowInt(i) {
+			return convertError(value, fieldType)
 		}
+		field.SetInt(i)
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		switch v := value.(type) {
-		case uint:
-			field.SetUint(uint64(v))
-		case uint8:
-			field.SetUint(uint64(v))
-		case uint16:
-			field.SetUint(uint64(v))
-		case uint32:
-			field.SetUint(uint64(v))
-		case uint64:
-			field.SetUint(v)
-		case string:
-			if i, err := strconv.ParseUint(v, 10, 64); err == nil {
-				field.SetUint(i)
-			}
-		case float64:
-			field.SetUint(uint64(v))
-		case float32:
-			field.SetUint(uint64(v))
+		var u uint64
+		var ok bool
+		if str, isStr := value.(string); isStr {
+			var err error
+			u, err = strconv.ParseUint(str, 10, 64)
+			ok = err == nil
+		} else {
+			u, ok = toUint64(reflect.ValueOf(value))
+		}
+		if !ok || field.OverflowUint(u) {
+			return convertError(value, fieldType)
 		}
+		field.SetUint(u)
 	case reflect.Float32, reflect.Float64:
-		switch v := value.(type) {
-		case float32:
-			field.SetFloat(float64(v))
-		case float64:
-			field.SetFloat(v)
-		case string:
-			if f, err := strconv.ParseFloat(v, 64); err == nil {
-				field.SetFloat(f)
+		var f float64
+		if str, ok := value.(string); ok {
+			var err error
+			if f, err = strconv.ParseFloat(str, 64); err != nil {
+				return convertError(value, fieldType)
 			}
-		case int:
-			field.SetFloat(float64(v))
-		case int64:
-			field.SetFloat(float64(v))
-		case uint:
-			field.SetFloat(float64(v))
-		case uint64:
-			field.SetFloat(float64(v))
+		} else if rv := reflect.ValueOf(value); isNumberKind(rv.Kind()) {
+			f = rv.Convert(reflect.TypeOf(float64(0))).Float()
+		} else {
+			return convertError(value, fieldType)
+		}
+		if field.OverflowFloat(f) {
+			return convertError(value, fieldType)
 		}
+		field.SetFloat(f)
 	case reflect.Bool:
 		switch v := value.(type) {
 		case bool:
@@ -242,34 +712,116 @@ func setValue(field reflect.Value, value interface{}) {
 			field.SetBool(v != 0)
 		case float32:
 			field.SetBool(v != 0)
+		default:
+			return convertError(value, fieldType)
 		}
 	case reflect.Struct:
 		// 如果目标字段是结构体，且源值是map，尝试递归转换
-		if srcMap, ok := value.(map[string]interface{}); ok {
-			tempStruct := reflect.New(fieldType).Interface()
-			MapToStruct(srcMap, tempStruct)
-			field.Set(reflect.ValueOf(tempStruct).Elem())
+		srcMap, ok := value.(map[string]interface{})
+		if !ok {
+			return convertError(value, fieldType)
 		}
+		tempStruct := reflect.New(fieldType).Elem()
+		err := o.mapToStruct(srcMap, tempStruct)
+		field.Set(tempStruct)
+		return err
 	case reflect.Ptr:
 		// 如果目标字段是指针，创建一个新实例并设置值
 		if field.IsNil() {
 			field.Set(reflect.New(fieldType.Elem()))
 		}
-		setValue(field.Elem(), value)
+		return o.setValue(field.Elem(), value)
 	case reflect.Slice:
 		// 如果目标字段是切片，且源值是切片
-		if srcSlice, ok := value.([]interface{}); ok {
-			sliceValue := reflect.MakeSlice(fieldType, len(srcSlice), len(srcSlice))
-			for i, v := range srcSlice {
-				setItem := sliceValue.Index(i)
-				setValue(setItem, v)
+		srcValue := reflect.ValueOf(value)
+		if srcValue.Kind() != reflect.Slice && srcValue.Kind() != reflect.Array {
+			return convertError(value, fieldType)
+		}
+		var errs []error
+		sliceValue := reflect.MakeSlice(fieldType, srcValue.Len(), srcValue.Len())
+		for i := 0; i < srcValue.Len(); i++ {
+			if err := o.setValue(sliceValue.Index(i), srcValue.Index(i).Interface()); err != nil {
+				errs = append(errs, fmt.Errorf("index %d: %w", i, err))
+			}
+		}
+		field.Set(sliceValue)
+		return errors.Join(errs...)
+	case reflect.Map:
+		// 如果目标字段是map，键和值分别按setValue的规则转换，如 {"1": "a"} -> map[int]string
+		srcValue := reflect.ValueOf(value)
+		if srcValue.Kind() != reflect.Map {
+			return convertError(value, fieldType)
+		}
+		var errs []error
+		mapValue := reflect.MakeMapWithSize(fieldType, srcValue.Len())
+		iter := srcValue.MapRange()
+		for iter.Next() {
+			k := reflect.New(fieldType.Key()).Elem()
+			if err := o.setValue(k, iter.Key().Interface()); err != nil {
+				errs = append(errs, fmt.Errorf("key %v: %w", iter.Key(), err))
+				continue
+			}
+			v := reflect.New(fieldType.Elem()).Elem()
+			if err := o.setValue(v, iter.Value().Interface()); err != nil {
+				errs = append(errs, fmt.Errorf("key %v: %w", iter.Key(), err))
+			}
+			mapValue.SetMapIndex(k, v)
+		}
+		field.Set(mapValue)
+		return errors.Join(errs...)
+	case reflect.Array:
+		// 如果目标字段是定长数组，按数组长度填充，多余元素忽略，不足部分置零
+		srcValue := reflect.ValueOf(value)
+		if srcValue.Kind() != reflect.Slice && srcValue.Kind() != reflect.Array {
+			return convertError(value, fieldType)
+		}
+		var errs []error
+		arrayValue := reflect.New(fieldType).Elem()
+		for i := 0; i < arrayValue.Len() && i < srcValue.Len(); i++ {
+			if err := o.setValue(arrayValue.Index(i), srcValue.Index(i).Interface()); err != nil {
+				errs = append(errs, fmt.Errorf("index %d: %w", i, err))
 			}
-			field.Set(sliceValue)
 		}
+		field.Set(arrayValue)
+		return errors.Join(errs...)
 	default:
 		// 其他情况尝试直接设置
-		if reflect.ValueOf(value).Type().ConvertibleTo(fieldType) {
-			field.Set(reflect.ValueOf(value).Convert(fieldType))
+		if !canConvert(reflect.ValueOf(value), fieldType) {
+			return convertError(value, fieldType)
+		}
+		field.Set(reflect.ValueOf(value).Convert(fieldType))
+	}
+	return nil
+}
+
+// parseTime 将字符串或Unix秒数解析为time.Time
+func (o *mapOptions) parseTime(value interface{}) (time.Time, bool) {
+	if str, ok := value.(string); ok {
+		layouts := []string{time.RFC3339Nano, time.DateTime, time.DateOnly}
+		if o.timeLayout != "" {
+			layouts = append([]string{o.timeLayout}, layouts...)
+		}
+		for _, layout := range layouts {
+			if t, err := time.Parse(layout, str); err == nil {
+				return t, true
+			}
 		}
+		return time.Time{}, false
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		sec, frac := math.Modf(rv.Float())
+		return time.Unix(int64(sec), int64(frac*1e9)), true
 	}
+	if sec, ok := toInt64(rv); ok {
+		return time.Unix(sec, 0), true
+	}
+	return time.Time{}, false
+}
+
+// convertError 返回值无法转换为目标类型的错误
+func convertError(value interface{}, t reflect.Type) error {
+	return fmt.Errorf("cannot convert %#v (%T) to %s", value, value, t)
 }
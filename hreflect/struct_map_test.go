@@ -1,7 +1,9 @@
 package hreflect
 
 import (
+	"strings"
 	"testing"
+	"time"
 )
 
 // TestStructToMap 测试结构体到map的转换
@@ -409,3 +411,731 @@ func TestComplexExample(t *testing.T) {
 		t.Errorf("Expected name to be 'Complex User', got %v", name)
 	}
 }
+
+// TestMapToStructWithArray 测试定长数组字段的转换
+func TestMapToStructWithArray(t *testing.T) {
+	type Point struct {
+		Coords [3]int `json:"coords"`
+	}
+
+	var p Point
+	if err := MapToStruct(map[string]interface{}{"coords": []interface{}{1, 2, 3}}, &p); err != nil {
+		t.Fatalf("MapToStruct failed: %v", err)
+	}
+	if p.Coords != [3]int{1, 2, 3} {
+		t.Errorf("Expected Coords to be [1 2 3], got %v", p.Coords)
+	}
+
+	// 输入不足时剩余元素置零
+	p = Point{Coords: [3]int{7, 8, 9}}
+	if err := MapToStruct(map[string]interface{}{"coords": []interface{}{4}}, &p); err != nil {
+		t.Fatalf("MapToStruct failed: %v", err)
+	}
+	if p.Coords != [3]int{4, 0, 0} {
+		t.Errorf("Expected Coords to be [4 0 0], got %v", p.Coords)
+	}
+
+	// 多余元素被忽略
+	if err := MapToStruct(map[string]interface{}{"coords": []interface{}{1, 2, 3, 4}}, &p); err != nil {
+		t.Fatalf("MapToStruct failed: %v", err)
+	}
+	if p.Coords != [3]int{1, 2, 3} {
+		t.Errorf("Expected Coords to be [1 2 3], got %v", p.Coords)
+	}
+}
+
+// TestMapToStructWithDuration 测试time.Duration字段的转换
+func TestMapToStructWithDuration(t *testing.T) {
+	type Config struct {
+		Timeout time.Duration `json:"timeout"`
+	}
+
+	cases := []struct {
+		value interface{}
+		want  time.Duration
+	}{
+		{"30s", 30 * time.Second},
+		{"1h30m", 90 * time.Minute},
+		{float64(1500), 1500 * time.Nanosecond}, // 数值按纳秒解释
+		{int64(time.Second), time.Second},
+		{"2000", 2000 * time.Nanosecond},
+	}
+	for _, c := range cases {
+		var cfg Config
+		if err := MapToStruct(map[string]interface{}{"timeout": c.value}, &cfg); err != nil {
+			t.Fatalf("MapToStruct failed: %v", err)
+		}
+		if cfg.Timeout != c.want {
+			t.Errorf("timeout %v: expected %v, got %v", c.value, c.want, cfg.Timeout)
+		}
+	}
+}
+
+// TestMapToStructWithNumericSlice 测试JSON数值切片(float64元素)的转换
+func TestMapToStructWithNumericSlice(t *testing.T) {
+	type Record struct {
+		IDs    []int     `json:"ids"`
+		Big    []int64   `json:"big"`
+		Scores []float64 `json:"scores"`
+		Names  []string  `json:"names"`
+		Small  []int8    `json:"small"`
+		Counts []uint    `json:"counts"`
+	}
+
+	data := map[string]interface{}{
+		"ids":    []interface{}{float64(1), float64(2), float64(3)},
+		"big":    []interface{}{float64(1 << 40), "7"},
+		"scores": []interface{}{float64(1.5), 2, "3.25"},
+		"names":  []interface{}{1, 2.5},
+		"small":  []interface{}{float64(100), float64(300)}, // 300溢出int8，跳过
+		"counts": []float64{4, -1},                          // 类型化切片，负数跳过
+	}
+
+	var r Record
+	if err := MapToStruct(data, &r); err != nil {
+		t.Fatalf("MapToStruct failed: %v", err)
+	}
+
+	if len(r.IDs) != 3 || r.IDs[0] != 1 || r.IDs[2] != 3 {
+		t.Errorf("Expected IDs to be [1 2 3], got %v", r.IDs)
+	}
+	if len(r.Big) != 2 || r.Big[0] != 1<<40 || r.Big[1] != 7 {
+		t.Errorf("Expected Big to be [%d 7], got %v", int64(1<<40), r.Big)
+	}
+	if len(r.Scores) != 3 || r.Scores[0] != 1.5 || r.Scores[1] != 2 || r.Scores[2] != 3.25 {
+		t.Errorf("Expected Scores to be [1.5 2 3.25], got %v", r.Scores)
+	}
+	if len(r.Names) != 2 || r.Names[0] != "1" || r.Names[1] != "2.5" {
+		t.Errorf("Expected Names to be [1 2.5], got %v", r.Names)
+	}
+	if len(r.Small) != 2 || r.Small[0] != 100 || r.Small[1] != 0 {
+		t.Errorf("Expected Small to be [100 0], got %v", r.Small)
+	}
+	if len(r.Counts) != 2 || r.Counts[0] != 4 || r.Counts[1] != 0 {
+		t.Errorf("Expected Counts to be [4 0], got %v", r.Counts)
+	}
+
+	// 带小数部分的浮点数不会被截断写入整数
+	var fractional Record
+	if err := MapToStruct(map[string]interface{}{"ids": []interface{}{float64(1.9)}}, &fractional); err != nil {
+		t.Fatalf("MapToStruct failed: %v", err)
+	}
+	if len(fractional.IDs) != 1 || fractional.IDs[0] != 0 {
+		t.Errorf("Expected fractional value to be skipped, got %v", fractional.IDs)
+	}
+}
+
+// TestStructToMapWithTimeLayout 测试time.Time字段格式化为字符串
+func TestStructToMapWithTimeLayout(t *testing.T) {
+	type Event struct {
+		Name      string     `json:"name"`
+		CreatedAt time.Time  `json:"created_at"`
+		UpdatedAt *time.Time `json:"updated_at"`
+		DeletedAt *time.Time `json:"deleted_at"`
+	}
+
+	ts := time.Date(2026, 1, 7, 10, 18, 30, 0, time.UTC)
+	event := Event{Name: "deploy", CreatedAt: ts, UpdatedAt: &ts}
+
+	result, err := StructToMap(event, WithTimeLayout(""))
+	if err != nil {
+		t.Fatalf("StructToMap failed: %v", err)
+	}
+	if result["created_at"] != "2026-01-07T10:18:30Z" {
+		t.Errorf("Expected created_at in RFC3339, got %v", result["created_at"])
+	}
+	if result["updated_at"] != "2026-01-07T10:18:30Z" {
+		t.Errorf("Expected updated_at in RFC3339, got %v", result["updated_at"])
+	}
+	if result["deleted_at"] != nil {
+		t.Errorf("Expected deleted_at to be nil, got %v", result["deleted_at"])
+	}
+
+	result, err = StructToMap(event, WithTimeLayout("2006-01-02 15:04:05"))
+	if err != nil {
+		t.Fatalf("StructToMap failed: %v", err)
+	}
+	if result["created_at"] != "2026-01-07 10:18:30" {
+		t.Errorf("Expected created_at in custom layout, got %v", result["created_at"])
+	}
+
+	// 未设置选项时保持原始值
+	result, _ = StructToMap(event)
+	if _, ok := result["created_at"].(time.Time); !ok {
+		t.Errorf("Expected raw time.Time without option, got %T", result["created_at"])
+	}
+}
+
+// TestMapFieldWithNonStringKeys 测试非字符串键的map字段双向转换
+func TestMapFieldWithNonStringKeys(t *testing.T) {
+	type Level int
+	type Catalog struct {
+		Names  map[int]string   `json:"names"`
+		Levels map[Level]string `json:"levels"`
+	}
+
+	src := Catalog{
+		Names:  map[int]string{1: "one", 2: "two"},
+		Levels: map[Level]string{10: "debug"},
+	}
+
+	// StructToMap 保留原始键类型
+	result, err := StructToMap(src)
+	if err != nil {
+		t.Fatalf("StructToMap failed: %v", err)
+	}
+	names, ok := result["names"].(map[int]string)
+	if !ok || names[1] != "one" {
+		t.Fatalf("Expected names to keep map[int]string, got %T %v", result["names"], result["names"])
+	}
+
+	var dst Catalog
+	if err := MapToStruct(result, &dst); err != nil {
+		t.Fatalf("MapToStruct failed: %v", err)
+	}
+	if len(dst.Names) != 2 || dst.Names[1] != "one" || dst.Names[2] != "two" || dst.Levels[10] != "debug" {
+		t.Errorf("round trip lost keys: %+v", dst)
+	}
+
+	// JSON风格的字符串键转换为目标键类型
+	var decoded Catalog
+	err = MapToStruct(map[string]interface{}{
+		"names":  map[string]interface{}{"1": "one", "2": "two"},
+		"levels": map[string]interface{}{"10": "debug"},
+	}, &decoded)
+	if err != nil {
+		t.Fatalf("MapToStruct failed: %v", err)
+	}
+	if decoded.Names[1] != "one" || decoded.Names[2] != "two" || decoded.Levels[Level(10)] != "debug" {
+		t.Errorf("string keys not converted: %+v", decoded)
+	}
+}
+
+// TestStructToMapDeep 测试递归转换嵌套结构体
+func TestStructToMapDeep(t *testing.T) {
+	type Address struct {
+		City    string `json:"city"`
+		Country string `json:"country"`
+	}
+	type Person struct {
+		Name      string    `json:"name"`
+		Address   Address   `json:"address"`
+		Office    *Address  `json:"office"`
+		Home      *Address  `json:"home"`
+		History   []Address `json:"history"`
+		Tags      []string  `json:"tags"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+
+	ts := time.Date(2026, 1, 7, 10, 18, 30, 0, time.UTC)
+	person := Person{
+		Name:      "Zhang San",
+		Address:   Address{City: "Beijing", Country: "China"},
+		Office:    &Address{City: "Shanghai", Country: "China"},
+		History:   []Address{{City: "Hangzhou"}, {City: "Shenzhen"}},
+		Tags:      []string{"a", "b"},
+		CreatedAt: ts,
+	}
+
+	result, err := StructToMap(person, WithDeep())
+	if err != nil {
+		t.Fatalf("StructToMap failed: %v", err)
+	}
+
+	addr, ok := result["address"].(map[string]interface{})
+	if !ok || addr["city"] != "Beijing" || addr["country"] != "China" {
+		t.Errorf("Expected address as map, got %T %v", result["address"], result["address"])
+	}
+	office, ok := result["office"].(map[string]interface{})
+	if !ok || office["city"] != "Shanghai" {
+		t.Errorf("Expected office as map, got %T %v", result["office"], result["office"])
+	}
+	if result["home"] != nil {
+		t.Errorf("Expected nil home, got %v", result["home"])
+	}
+	history, ok := result["history"].([]map[string]interface{})
+	if !ok || len(history) != 2 || history[1]["city"] != "Shenzhen" {
+		t.Errorf("Expected history as []map, got %T %v", result["history"], result["history"])
+	}
+	if tags, ok := result["tags"].([]string); !ok || len(tags) != 2 {
+		t.Errorf("Expected tags unchanged, got %T %v", result["tags"], result["tags"])
+	}
+	if result["created_at"] != ts {
+		t.Errorf("Expected created_at to keep time.Time, got %T", result["created_at"])
+	}
+
+	// 与WithTimeLayout组合时嵌套的时间同样格式化
+	type Wrapper struct {
+		Person Person `json:"person"`
+	}
+	result, _ = StructToMap(Wrapper{Person: person}, WithDeep(), WithTimeLayout(""))
+	inner, _ := result["person"].(map[string]interface{})
+	if inner["created_at"] != "2026-01-07T10:18:30Z" {
+		t.Errorf("Expected nested created_at formatted, got %v", inner["created_at"])
+	}
+
+	// 默认保持浅层转换
+	result, _ = StructToMap(person)
+	if _, ok := result["address"].(Address); !ok {
+		t.Errorf("Expected raw Address without option, got %T", result["address"])
+	}
+}
+
+// TestStructToMapDeepPointerSlices 测试结构体指针切片和结构体数组的递归转换
+func TestStructToMapDeepPointerSlices(t *testing.T) {
+	type Item struct {
+		SKU string `json:"sku"`
+		Qty int    `json:"qty"`
+	}
+	type Cart struct {
+		Items   []*Item  `json:"items"`
+		Slots   [3]Item  `json:"slots"`
+		Pinned  [2]*Item `json:"pinned"`
+		Removed []*Item  `json:"removed"`
+	}
+	cart := Cart{
+		Items:  []*Item{{SKU: "a", Qty: 1}, nil, {SKU: "c", Qty: 3}},
+		Slots:  [3]Item{{SKU: "x"}, {SKU: "y"}, {SKU: "z"}},
+		Pinned: [2]*Item{nil, {SKU: "p"}},
+	}
+
+	result, err := StructToMap(&cart, WithDeep())
+	if err != nil {
+		t.Fatalf("StructToMap failed: %v", err)
+	}
+
+	items, ok := result["items"].([]map[string]interface{})
+	if !ok || len(items) != 3 || items[0]["sku"] != "a" || items[1] != nil || items[2]["qty"] != 3 {
+		t.Errorf("Expected items as []map with nil entry, got %T %v", result["items"], result["items"])
+	}
+	slots, ok := result["slots"].([]map[string]interface{})
+	if !ok || len(slots) != 3 || slots[2]["sku"] != "z" {
+		t.Errorf("Expected slots as []map, got %T %v", result["slots"], result["slots"])
+	}
+	pinned, ok := result["pinned"].([]map[string]interface{})
+	if !ok || len(pinned) != 2 || pinned[0] != nil || pinned[1]["sku"] != "p" {
+		t.Errorf("Expected pinned as []map, got %T %v", result["pinned"], result["pinned"])
+	}
+	if removed, ok := result["removed"].([]map[string]interface{}); !ok || removed != nil {
+		t.Errorf("Expected nil removed slice, got %T %v", result["removed"], result["removed"])
+	}
+}
+
+// TestStructToMapOmitEmpty 测试omitempty选项跳过各类零值字段
+func TestStructToMapOmitEmpty(t *testing.T) {
+	type Item struct {
+		Name   string                 `json:"name,omitempty"`
+		Count  int                    `json:"count,omitempty"`
+		Size   uint                   `json:"size,omitempty"`
+		Ratio  float64                `json:"ratio,omitempty"`
+		Active bool                   `json:"active,omitempty"`
+		Parent *Item                  `json:"parent,omitempty"`
+		Tags   []string               `json:"tags,omitempty"`
+		Meta   map[string]string      `json:"meta,omitempty"`
+		Extra  interface{}            `json:"extra,omitempty"`
+		Attrs  map[string]interface{} `json:",omitempty"`
+		Kept   int                    `json:"kept"`
+	}
+
+	result, err := StructToMap(Item{})
+	if err != nil {
+		t.Fatalf("StructToMap failed: %v", err)
+	}
+	if len(result) != 1 {
+		t.Errorf("Expected only kept field, got %v", result)
+	}
+	if v, ok := result["kept"]; !ok || v != 0 {
+		t.Errorf("Expected kept to be 0, got %v", v)
+	}
+
+	full := Item{
+		Name:   "a",
+		Count:  1,
+		Size:   2,
+		Ratio:  0.5,
+		Active: true,
+		Parent: &Item{},
+		Tags:   []string{"x"},
+		Meta:   map[string]string{"k": "v"},
+		Extra:  "e",
+		Attrs:  map[string]interface{}{"k": 1},
+	}
+	result, err = StructToMap(full)
+	if err != nil {
+		t.Fatalf("StructToMap failed: %v", err)
+	}
+	for _, key := range []string{"name", "count", "size", "ratio", "active", "parent", "tags", "meta", "extra", "Attrs", "kept"} {
+		if _, ok := result[key]; !ok {
+			t.Errorf("Expected %s to be present, got %v", key, result)
+		}
+	}
+
+	// 空切片和空map同样视为零值
+	result, _ = StructToMap(Item{Tags: []string{}, Meta: map[string]string{}})
+	if _, ok := result["tags"]; ok {
+		t.Errorf("Expected empty tags to be omitted")
+	}
+	if _, ok := result["meta"]; ok {
+		t.Errorf("Expected empty meta to be omitted")
+	}
+}
+
+// TestStructToMapEmbedded 测试匿名嵌入结构体的字段提升
+func TestStructToMapEmbedded(t *testing.T) {
+	type Base struct {
+		ID        int    `json:"id"`
+		CreatedBy string `json:"created_by"`
+		Name      string `json:"name"`
+	}
+	type Audit struct {
+		Version int `json:"version"`
+	}
+	type Meta struct {
+		Source string `json:"source"`
+	}
+	type User struct {
+		Base
+		*Audit
+		Meta  `json:"meta"`
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	}
+
+	user := User{
+		Base:  Base{ID: 7, CreatedBy: "admin", Name: "base"},
+		Audit: &Audit{Version: 3},
+		Meta:  Meta{Source: "api"},
+		Name:  "outer",
+		Email: "a@example.com",
+	}
+
+	result, err := StructToMap(user)
+	if err != nil {
+		t.Fatalf("StructToMap failed: %v", err)
+	}
+	if result["id"] != 7 || result["created_by"] != "admin" {
+		t.Errorf("Expected Base fields promoted, got %v", result)
+	}
+	if result["version"] != 3 {
+		t.Errorf("Expected *Audit fields promoted, got %v", result)
+	}
+	if result["name"] != "outer" {
+		t.Errorf("Expected outer name to win, got %v", result["name"])
+	}
+	if _, ok := result["Base"]; ok {
+		t.Errorf("Expected no Base key, got %v", result)
+	}
+	if _, ok := result["meta"].(Meta); !ok {
+		t.Errorf("Expected tagged embedded field kept as value, got %T", result["meta"])
+	}
+	if len(result) != 6 {
+		t.Errorf("Expected 6 keys, got %v", result)
+	}
+
+	// nil匿名指针不输出任何字段
+	user.Audit = nil
+	result, _ = StructToMap(user)
+	if _, ok := result["version"]; ok {
+		t.Errorf("Expected nil *Audit to be skipped, got %v", result)
+	}
+}
+
+// TestMapToStructStrict 测试严格模式下返回转换失败的字段和值
+func TestMapToStructStrict(t *testing.T) {
+	type Address struct {
+		Zip int `json:"zip"`
+	}
+	type Config struct {
+		Host    string  `json:"host"`
+		Port    int     `json:"port"`
+		Ratio   float64 `json:"ratio"`
+		Ports   []uint  `json:"ports"`
+		Address Address `json:"address"`
+	}
+
+	data := map[string]interface{}{
+		"host":    "localhost",
+		"port":    "abc",
+		"ratio":   "0.5",
+		"ports":   []interface{}{80, -1},
+		"address": map[string]interface{}{"zip": "x1"},
+	}
+
+	// 默认宽松模式不返回错误
+	var lenient Config
+	if err := MapToStruct(data, &lenient); err != nil {
+		t.Fatalf("Expected lenient mode to succeed, got %v", err)
+	}
+	if lenient.Host != "localhost" || lenient.Port != 0 {
+		t.Errorf("Unexpected lenient result: %+v", lenient)
+	}
+
+	var strict Config
+	err := MapToStruct(data, &strict, WithStrict())
+	if err == nil {
+		t.Fatal("Expected strict mode to return an error")
+	}
+	msg := err.Error()
+	for _, want := range []string{`field "port"`, `"abc"`, `field "ports": index 1`, `field "address": field "zip"`, `"x1"`} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("Expected error to contain %s, got %q", want, msg)
+		}
+	}
+	if strings.Contains(msg, `field "ratio"`) || strings.Contains(msg, `field "host"`) {
+		t.Errorf("Unexpected field in error: %q", msg)
+	}
+	// 可转换的字段仍然写入
+	if strict.Host != "localhost" || strict.Ratio != 0.5 || len(strict.Ports) != 2 || strict.Ports[0] != 80 {
+		t.Errorf("Unexpected strict result: %+v", strict)
+	}
+
+	// 超出float32范围的值不会被静默转换为+Inf
+	type Sample struct {
+		Rate float32 `json:"rate"`
+	}
+	var sample Sample
+	err = MapToStruct(map[string]interface{}{"rate": 1e40}, &sample, WithStrict())
+	if err == nil || !strings.Contains(err.Error(), `field "rate"`) || sample.Rate != 0 {
+		t.Errorf("Expected float32 overflow to fail, got %v, %+v", err, sample)
+	}
+
+	// 全部可转换时严格模式返回nil
+	if err := MapToStruct(map[string]interface{}{"port": "8080"}, &strict, WithStrict()); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+// TestMapToStructWithTime 测试time.Time字段的解析和往返转换
+func TestMapToStructWithTime(t *testing.T) {
+	type Event struct {
+		Name      string     `json:"name"`
+		CreatedAt time.Time  `json:"created_at"`
+		UpdatedAt *time.Time `json:"updated_at"`
+	}
+
+	ts := time.Date(2026, 1, 7, 10, 18, 30, 0, time.UTC)
+
+	// 按RFC3339往返
+	src := Event{Name: "deploy", CreatedAt: ts, UpdatedAt: &ts}
+	data, err := StructToMap(src, WithTimeLayout(""))
+	if err != nil {
+		t.Fatalf("StructToMap failed: %v", err)
+	}
+	var dst Event
+	if err := MapToStruct(data, &dst, WithStrict()); err != nil {
+		t.Fatalf("MapToStruct failed: %v", err)
+	}
+	if !dst.CreatedAt.Equal(ts) || dst.UpdatedAt == nil || !dst.UpdatedAt.Equal(ts) {
+		t.Errorf("round trip mismatch: %+v", dst)
+	}
+
+	// 自定义layout往返
+	layout := "2006/01/02 15:04"
+	data, _ = StructToMap(src, WithTimeLayout(layout))
+	dst = Event{}
+	if err := MapToStruct(data, &dst, WithTimeLayout(layout), WithStrict()); err != nil {
+		t.Fatalf("MapToStruct failed: %v", err)
+	}
+	if !dst.CreatedAt.Equal(ts.Truncate(time.Minute)) {
+		t.Errorf("custom layout mismatch: %v", dst.CreatedAt)
+	}
+
+	// 原始time.Time往返
+	data, _ = StructToMap(src)
+	dst = Event{}
+	if err := MapToStruct(data, &dst, WithStrict()); err != nil {
+		t.Fatalf("MapToStruct failed: %v", err)
+	}
+	if !dst.CreatedAt.Equal(ts) {
+		t.Errorf("raw time mismatch: %v", dst.CreatedAt)
+	}
+
+	// Unix秒数
+	cases := []struct {
+		value interface{}
+		want  time.Time
+	}{
+		{ts.Unix(), ts},
+		{float64(ts.Unix()) + 0.5, ts.Add(500 * time.Millisecond)},
+		{"2026-01-07 10:18:30", ts},
+		{"2026-01-07", time.Date(2026, 1, 7, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		dst = Event{}
+		if err := MapToStruct(map[string]interface{}{"created_at": c.value}, &dst, WithStrict()); err != nil {
+			t.Fatalf("MapToStruct %v failed: %v", c.value, err)
+		}
+		if !dst.CreatedAt.Equal(c.want) {
+			t.Errorf("value %v: expected %v, got %v", c.value, c.want, dst.CreatedAt)
+		}
+	}
+
+	// 无法解析的字符串在严格模式下报错
+	if err := MapToStruct(map[string]interface{}{"created_at": "yesterday"}, &dst, WithStrict()); err == nil {
+		t.Error("Expected error for unparseable time")
+	}
+}
+
+// TestMapToStructWithMapFields 测试map[string]string、map[string]interface{}及需要转换的类型化map字段
+func TestMapToStructWithMapFields(t *testing.T) {
+	type Service struct {
+		Labels   map[string]string      `json:"labels"`
+		Metadata map[string]interface{} `json:"metadata"`
+		Weights  map[string]int         `json:"weights"`
+		Limits   *map[string]float64    `json:"limits"`
+	}
+
+	data := map[string]interface{}{
+		"labels":   map[string]interface{}{"app": "api", "replicas": 3},
+		"metadata": map[string]interface{}{"owner": "ops", "tags": []interface{}{"a"}},
+		"weights":  map[string]interface{}{"a": float64(1), "b": "2"},
+		"limits":   map[string]interface{}{"cpu": "0.5"},
+	}
+
+	var svc Service
+	if err := MapToStruct(data, &svc, WithStrict()); err != nil {
+		t.Fatalf("MapToStruct failed: %v", err)
+	}
+	if svc.Labels["app"] != "api" || svc.Labels["replicas"] != "3" {
+		t.Errorf("unexpected labels: %v", svc.Labels)
+	}
+	if svc.Metadata["owner"] != "ops" || len(svc.Metadata["tags"].([]interface{})) != 1 {
+		t.Errorf("unexpected metadata: %v", svc.Metadata)
+	}
+	if svc.Weights["a"] != 1 || svc.Weights["b"] != 2 {
+		t.Errorf("unexpected weights: %v", svc.Weights)
+	}
+	if svc.Limits == nil || (*svc.Limits)["cpu"] != 0.5 {
+		t.Errorf("unexpected limits: %v", svc.Limits)
+	}
+
+	// 值无法转换时严格模式报告对应的键
+	err := MapToStruct(map[string]interface{}{
+		"weights": map[string]interface{}{"c": "heavy"},
+	}, &svc, WithStrict())
+	if err == nil || !strings.Contains(err.Error(), `field "weights": key c`) {
+		t.Errorf("Expected weights key error, got %v", err)
+	}
+}
+
+// TestStructToMapUnexported 测试未导出字段跳过，未导出匿名结构体提升的导出字段仍然输出
+func TestStructToMapUnexported(t *testing.T) {
+	type base struct {
+		ID     int `json:"id"`
+		secret string
+	}
+	type Account struct {
+		base
+		Name  string `json:"name"`
+		token string
+		level int
+	}
+	account := Account{base: base{ID: 9, secret: "s"}, Name: "n", token: "t", level: 2}
+
+	for _, input := range []interface{}{account, &account} {
+		result, err := StructToMap(input)
+		if err != nil {
+			t.Fatalf("StructToMap failed: %v", err)
+		}
+		want := map[string]interface{}{"id": 9, "name": "n"}
+		if len(result) != len(want) || result["id"] != 9 || result["name"] != "n" {
+			t.Errorf("%T: expected %v, got %v", input, want, result)
+		}
+	}
+	// 经由未导出的匿名结构体指针提升的导出字段同样输出
+	type Session struct {
+		*base
+		token string
+	}
+	result, err := StructToMap(Session{base: &base{ID: 3, secret: "s"}, token: "t"})
+	if err != nil {
+		t.Fatalf("StructToMap failed: %v", err)
+	}
+	if len(result) != 1 || result["id"] != 3 {
+		t.Errorf("expected only promoted id, got %v", result)
+	}
+}
+
+// TestMergeStructToMap 测试合并多个结构体时后合并的覆盖同名键
+func TestMergeStructToMap(t *testing.T) {
+	type Order struct {
+		ID     int    `json:"id"`
+		Status string `json:"status"`
+	}
+	type Shipment struct {
+		Status  string `json:"status"`
+		Carrier string `json:"carrier"`
+		Note    string `json:"note,omitempty"`
+	}
+
+	dst := map[string]interface{}{"note": "keep"}
+	if err := MergeStructToMap(dst, Order{ID: 1, Status: "paid"}); err != nil {
+		t.Fatalf("MergeStructToMap failed: %v", err)
+	}
+	if err := MergeStructToMap(dst, &Shipment{Status: "shipped", Carrier: "ups"}); err != nil {
+		t.Fatalf("MergeStructToMap failed: %v", err)
+	}
+
+	want := map[string]interface{}{"id": 1, "status": "shipped", "carrier": "ups", "note": "keep"}
+	if len(dst) != len(want) {
+		t.Fatalf("expected %v, got %v", want, dst)
+	}
+	for key, value := range want {
+		if dst[key] != value {
+			t.Errorf("key %q: expected %v, got %v", key, value, dst[key])
+		}
+	}
+
+	if err := MergeStructToMap(nil, Order{}); err == nil {
+		t.Error("expected error for nil dst")
+	}
+	if err := MergeStructToMap(dst, 42); err == nil {
+		t.Error("expected error for non-struct input")
+	}
+}
+
+// TestStructDiff 测试比较两个结构体返回变化的字段
+func TestStructDiff(t *testing.T) {
+	type Address struct {
+		City string `json:"city"`
+	}
+	type User struct {
+		ID      int      `json:"id"`
+		Name    string   `json:"name"`
+		Email   string   `json:"email,omitempty"`
+		Tags    []string `json:"tags"`
+		Address Address  `json:"address"`
+		Secret  string   `json:"-"`
+		version int
+	}
+
+	oldUser := User{ID: 1, Name: "a", Email: "a@example.com", Tags: []string{"x"}, Address: Address{City: "Beijing"}, Secret: "s1", version: 1}
+	newUser := oldUser
+	newUser.Name = "b"
+	newUser.Email = ""
+	newUser.Tags = []string{"x"}
+	newUser.Address = Address{City: "Shanghai"}
+	newUser.Secret = "s2"
+	newUser.version = 2
+
+	diff, err := StructDiff(oldUser, &newUser)
+	if err != nil {
+		t.Fatalf("StructDiff failed: %v", err)
+	}
+	want := map[string]interface{}{"name": "b", "email": "", "address": Address{City: "Shanghai"}}
+	if len(diff) != len(want) {
+		t.Fatalf("expected %v, got %v", want, diff)
+	}
+	for key, value := range want {
+		if diff[key] != value {
+			t.Errorf("key %q: expected %v, got %v", key, value, diff[key])
+		}
+	}
+
+	if diff, _ := StructDiff(oldUser, oldUser); len(diff) != 0 {
+		t.Errorf("expected no diff for identical structs, got %v", diff)
+	}
+	if _, err := StructDiff(oldUser, Address{}); err == nil {
+		t.Error("expected error for different types")
+	}
+}
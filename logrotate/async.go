@@ -0,0 +1,173 @@
+package logrotate
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// OverflowPolicy 异步写入队列满时的处理策略
+type OverflowPolicy int
+
+const (
+	OverflowBlock      OverflowPolicy = iota // 阻塞等待, 不丢失日志
+	OverflowDropNewest                       // 丢弃当前写入的日志
+	OverflowDropOldest                       // 丢弃队列中最早的日志
+)
+
+// asyncItem 队列元素, done不为nil时表示Sync请求, 执行结果通过done返回
+type asyncItem struct {
+	data []byte
+	done chan error
+}
+
+// AsyncWriter 将写入放入队列, 由后台协程写入底层WriteSyncer
+type AsyncWriter struct {
+	ws      WriteSyncer
+	queue   chan asyncItem
+	policy  OverflowPolicy
+	dropped atomic.Int64
+
+	wsMu     sync.Mutex   // 保护对底层ws的操作
+	syncMu   sync.Mutex   // 保护deferred
+	deferred []asyncItem  // OverflowDropOldest时从队列中取出的Sync请求, 由后台协程执行
+	closeMu  sync.RWMutex // 保证Close后不再向队列写入
+	closed   bool
+	workerWg sync.WaitGroup
+}
+
+// NewAsyncWriter 创建异步写入器, queueSize为队列可缓存的写入次数
+func NewAsyncWriter(ws WriteSyncer, queueSize int, policy OverflowPolicy) *AsyncWriter {
+	if queueSize <= 0 {
+		queueSize = 1
+	}
+	aw := &AsyncWriter{
+		ws:     ws,
+		queue:  make(chan asyncItem, queueSize),
+		policy: policy,
+	}
+	aw.workerWg.Add(1)
+	go aw.run()
+	return aw
+}
+
+// run 后台写入协程
+func (aw *AsyncWriter) run() {
+	defer aw.workerWg.Done()
+	for item := range aw.queue {
+		aw.handleDeferred()
+		aw.handle(item)
+	}
+	aw.handleDeferred()
+}
+
+// handleDeferred 执行从队列中取出的Sync请求, 这些请求之前的数据已由后台协程写入
+func (aw *AsyncWriter) handleDeferred() {
+	aw.syncMu.Lock()
+	deferred := aw.deferred
+	aw.deferred = nil
+	aw.syncMu.Unlock()
+
+	for _, item := range deferred {
+		aw.handle(item)
+	}
+}
+
+// handle 写入数据或执行Sync请求
+func (aw *AsyncWriter) handle(item asyncItem) {
+	aw.wsMu.Lock()
+	defer aw.wsMu.Unlock()
+
+	if item.done != nil {
+		item.done <- aw.ws.Sync()
+		return
+	}
+	_, _ = aw.ws.Write(item.data)
+}
+
+// Write 实现io.Writer接口, 数据会被复制后放入队列
+func (aw *AsyncWriter) Write(p []byte) (int, error) {
+	aw.closeMu.RLock()
+	defer aw.closeMu.RUnlock()
+
+	if aw.closed {
+		return 0, io.ErrClosedPipe
+	}
+
+	// 调用方(如zap)可能复用p, 必须复制
+	item := asyncItem{data: append([]byte(nil), p...)}
+	switch aw.policy {
+	case OverflowDropNewest:
+		select {
+		case aw.queue <- item:
+		default:
+			aw.dropped.Add(1)
+		}
+	case OverflowDropOldest:
+		for {
+			select {
+			case aw.queue <- item:
+				return len(p), nil
+			default:
+			}
+			select {
+			case old := <-aw.queue:
+				if old.done != nil {
+					// Sync请求不能丢弃, 后台协程可能仍在写入更早取走的数据, 交给后台协程在处理下一项前执行
+					aw.syncMu.Lock()
+					aw.deferred = append(aw.deferred, old)
+					aw.syncMu.Unlock()
+				} else {
+					aw.dropped.Add(1)
+				}
+			default:
+			}
+		}
+	default:
+		aw.queue <- item
+	}
+	return len(p), nil
+}
+
+// Sync 等待此前写入的数据全部写入底层并同步, 返回底层Sync的错误
+func (aw *AsyncWriter) Sync() error {
+	aw.closeMu.RLock()
+	if aw.closed {
+		aw.closeMu.RUnlock()
+		return nil
+	}
+	done := make(chan error, 1)
+	aw.queue <- asyncItem{done: done}
+	aw.closeMu.RUnlock()
+
+	return <-done
+}
+
+// DroppedCount 返回因队列满被丢弃的写入次数
+func (aw *AsyncWriter) DroppedCount() int64 {
+	return aw.dropped.Load()
+}
+
+// Close 写完队列中剩余数据后关闭底层写入器
+func (aw *AsyncWriter) Close() error {
+	aw.closeMu.Lock()
+	if aw.closed {
+		aw.closeMu.Unlock()
+		return nil
+	}
+	aw.closed = true
+	close(aw.queue)
+	aw.closeMu.Unlock()
+
+	aw.workerWg.Wait()
+
+	aw.wsMu.Lock()
+	defer aw.wsMu.Unlock()
+	if err := aw.ws.Sync(); err != nil {
+		return err
+	}
+	if closer, ok := aw.ws.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package logrotate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// slowSink 每次写入都会延迟的WriteSyncer
+type slowSink struct {
+	mu    sync.Mutex
+	buf   bytes.Buffer
+	lines int
+	delay time.Duration
+}
+
+func (s *slowSink) Write(p []byte) (int, error) {
+	time.Sleep(s.delay)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lines++
+	return s.buf.Write(p)
+}
+
+func (s *slowSink) Sync() error { return nil }
+
+func TestAsyncWriterOverflow(t *testing.T) {
+	const total = 50
+
+	cases := []struct {
+		name   string
+		policy OverflowPolicy
+	}{
+		{"Block", OverflowBlock},
+		{"DropNewest", OverflowDropNewest},
+		{"DropOldest", OverflowDropOldest},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink := &slowSink{delay: time.Millisecond}
+			aw := NewAsyncWriter(sink, 2, c.policy)
+			for i := 0; i < total; i++ {
+				fmt.Fprintf(aw, "line %d\n", i)
+			}
+			if err := aw.Close(); err != nil {
+				t.Fatalf("Close failed: %v", err)
+			}
+
+			dropped := aw.DroppedCount()
+			if int64(sink.lines)+dropped != total {
+				t.Errorf("written %d + dropped %d != %d", sink.lines, dropped, total)
+			}
+			switch c.policy {
+			case OverflowBlock:
+				if dropped != 0 || sink.lines != total {
+					t.Errorf("Block should lose nothing, written %d dropped %d", sink.lines, dropped)
+				}
+			default:
+				if dropped == 0 {
+					t.Errorf("expected drops with a tiny queue and slow sink")
+				}
+			}
+			if c.policy == OverflowDropOldest && !bytes.HasSuffix(sink.buf.Bytes(), []byte(fmt.Sprintf("line %d\n", total-1))) {
+				t.Errorf("DropOldest should keep the newest line, got %q", sink.buf.String())
+			}
+		})
+	}
+}
+
+func TestAsyncWriterSync(t *testing.T) {
+	sink := &slowSink{delay: time.Millisecond}
+	aw := NewAsyncWriter(sink, 100, OverflowBlock)
+	defer aw.Close()
+
+	for i := 0; i < 10; i++ {
+		fmt.Fprintf(aw, "line %d\n", i)
+	}
+	aw.Sync()
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	if sink.lines != 10 {
+		t.Errorf("expected 10 lines after Sync, got %d", sink.lines)
+	}
+}
+
+// gateSink 写入阻塞到gate关闭的WriteSyncer, 开始写入时通知entered
+type gateSink struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	gate    chan struct{}
+	entered chan struct{}
+	syncErr error
+}
+
+func (s *gateSink) Write(p []byte) (int, error) {
+	select {
+	case s.entered <- struct{}{}:
+	default:
+	}
+	<-s.gate
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.Write(p)
+}
+
+func (s *gateSink) Sync() error { return s.syncErr }
+
+func (s *gateSink) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.String()
+}
+
+// TestAsyncWriterDropOldestSync 测试DropOldest取出的Sync请求由后台协程执行, 仍等待此前的数据写入
+func TestAsyncWriterDropOldestSync(t *testing.T) {
+	sink := &gateSink{gate: make(chan struct{}), entered: make(chan struct{}, 1)}
+	aw := NewAsyncWriter(sink, 1, OverflowDropOldest)
+	var openGate sync.Once
+	defer func() {
+		// 失败时也放行写入, 避免Close阻塞
+		openGate.Do(func() { close(sink.gate) })
+		aw.Close()
+	}()
+
+	// 后台协程阻塞在写入a
+	aw.Write([]byte("a\n"))
+	<-sink.entered
+
+	syncErr := make(chan error, 1)
+	go func() { syncErr <- aw.Sync() }()
+	for len(aw.queue) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// 队列已满, 取出Sync请求后写入b, 不应阻塞
+	wrote := make(chan struct{})
+	go func() {
+		aw.Write([]byte("b\n"))
+		close(wrote)
+	}()
+	select {
+	case <-wrote:
+	case <-time.After(time.Second):
+		t.Fatal("Write blocked on a dequeued Sync request")
+	}
+	select {
+	case <-syncErr:
+		t.Fatal("Sync returned before earlier data was written")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	openGate.Do(func() { close(sink.gate) })
+	if err := <-syncErr; err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if got := sink.String(); !bytes.HasPrefix([]byte(got), []byte("a\n")) {
+		t.Errorf("expected earlier data to be written before Sync returned, got %q", got)
+	}
+}
+
+// TestAsyncWriterSyncError 测试Sync和Close返回底层Sync的错误
+func TestAsyncWriterSyncError(t *testing.T) {
+	diskErr := errors.New("disk failure")
+	gate := make(chan struct{})
+	close(gate)
+	sink := &gateSink{gate: gate, entered: make(chan struct{}, 1), syncErr: diskErr}
+	aw := NewAsyncWriter(sink, 10, OverflowBlock)
+
+	aw.Write([]byte("line\n"))
+	if err := aw.Sync(); !errors.Is(err, diskErr) {
+		t.Errorf("expected Sync to return the sink error, got %v", err)
+	}
+	if err := aw.Close(); !errors.Is(err, diskErr) {
+		t.Errorf("expected Close to return the sink error, got %v", err)
+	}
+}
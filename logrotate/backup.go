@@ -0,0 +1,400 @@
+package logrotate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// backupFile 目录中属于当前写入器的轮转文件
+type backupFile struct {
+	base  string    // 未压缩时的文件路径
+	stamp time.Time // 文件名中的时间戳
+	index int       // 同一时间窗口内按大小滚动的序号, 0表示该窗口最新的文件
+}
+
+// timeLayout 获取文件名中时间戳的格式
+func (rw *RotateWriter) timeLayout() string {
+	if rw.config.TimeFormat != "" {
+		return rw.config.TimeFormat
+	}
+	switch rw.config.TimeRotation {
+	case "monthly":
+		return "2006-01" // 年-月
+	case "hourly":
+		return "2006-01-02_15" // 年-月-日_时
+	case "minutely":
+		return "2006-01-02_15_04" // 年-月-日_时_分
+	default: // daily
+		return "2006-01-02" // 年-月-日
+	}
+}
+
+// isoWeekStamp 是否使用ISO周格式的时间戳(如2026-W02), time的格式化布局无法表示ISO周
+func (rw *RotateWriter) isoWeekStamp() bool {
+	return rw.config.TimeRotation == "weekly" && rw.config.TimeFormat == ""
+}
+
+// formatStamp 格式化文件名中的时间戳
+func (rw *RotateWriter) formatStamp(t time.Time) string {
+	if rw.isoWeekStamp() {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%04d-W%02d", year, week)
+	}
+	return t.Format(rw.timeLayout())
+}
+
+// parseStamp 解析文件名中的时间戳, ISO周格式返回该周周一零点
+func (rw *RotateWriter) parseStamp(s string) (time.Time, error) {
+	if !rw.isoWeekStamp() {
+		return time.ParseInLocation(rw.timeLayout(), s, rw.location())
+	}
+
+	var year, week int
+	if _, err := fmt.Sscanf(s, "%04d-W%02d", &year, &week); err != nil {
+		return time.Time{}, err
+	}
+	// 1月4日总在ISO第1周内
+	jan4 := time.Date(year, 1, 4, 0, 0, 0, 0, rw.location())
+	monday := jan4.AddDate(0, 0, -(int(jan4.Weekday())+6)%7+(week-1)*7)
+	if rw.formatStamp(monday) != s {
+		return time.Time{}, fmt.Errorf("invalid iso week stamp %q", s)
+	}
+	return monday, nil
+}
+
+// listBackups 列出目录中与filePrefix/fileExt匹配的轮转文件, 压缩与未压缩的同名文件视为同一个, 按时间戳和序号从旧到新排列
+func (rw *RotateWriter) listBackups() ([]backupFile, error) {
+	dir := filepath.Dir(rw.filePrefix)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	namePrefix := filepath.Base(rw.filePrefix) + "_"
+	seen := make(map[string]bool)
+	var backups []backupFile
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := strings.TrimSuffix(entry.Name(), compressSuffix)
+		if seen[name] || !strings.HasPrefix(name, namePrefix) || !strings.HasSuffix(name, rw.fileExt) {
+			continue
+		}
+		timePart := strings.TrimSuffix(strings.TrimPrefix(name, namePrefix), rw.fileExt)
+		stamp, index, err := rw.parseBackupStamp(timePart)
+		if err != nil {
+			// 与当前写入器无关的文件
+			continue
+		}
+		seen[name] = true
+		backups = append(backups, backupFile{base: filepath.Join(dir, name), stamp: stamp, index: index})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		a, b := backups[i], backups[j]
+		if !a.stamp.Equal(b.stamp) {
+			return a.stamp.Before(b.stamp)
+		}
+		// 同一时间窗口内序号文件早于当前文件, 序号小的更早
+		if (a.index == 0) != (b.index == 0) {
+			return b.index == 0
+		}
+		return a.index < b.index
+	})
+	return backups, nil
+}
+
+// parseBackupStamp 解析轮转文件名中的时间戳, 支持按大小滚动产生的带序号形式(如2026-01-07.1)
+func (rw *RotateWriter) parseBackupStamp(s string) (time.Time, int, error) {
+	stamp, err := rw.parseStamp(s)
+	if err == nil {
+		return stamp, 0, nil
+	}
+	i := strings.LastIndex(s, ".")
+	if i < 0 {
+		return time.Time{}, 0, err
+	}
+	index, ierr := strconv.Atoi(s[i+1:])
+	if ierr != nil || index <= 0 {
+		return time.Time{}, 0, err
+	}
+	stamp, err = rw.parseStamp(s[:i])
+	return stamp, index, err
+}
+
+// cleanupBackups 按MaxBackups和MaxTotalSize清理轮转文件, 调用约束同removeOldBackups
+func (rw *RotateWriter) cleanupBackups(currentPath string) {
+	rw.removeOldBackups(currentPath)
+	rw.removeOversized(currentPath)
+}
+
+// removeOldBackups 删除超出MaxBackups的最旧轮转文件, currentPath为正在写入的文件, 它及更新的(如预打开的)文件不计入备份
+//
+// 仅操作目录中的文件, 不访问写入器的可变状态, 持有rw.mu时或在后台协程中调用均可
+func (rw *RotateWriter) removeOldBackups(currentPath string) {
+	// 按序号滚动时由rollIndexed负责清理
+	if rw.config.MaxBackups <= 0 || !rw.timeRotationEnabled() {
+		return
+	}
+
+	backups, err := rw.listBackups()
+	if err != nil {
+		return
+	}
+
+	var current time.Time
+	for _, b := range backups {
+		if b.base == currentPath {
+			current = b.stamp
+		}
+	}
+	n := 0
+	for _, b := range backups {
+		if b.base != currentPath && (current.IsZero() || b.stamp.Before(current)) {
+			backups[n] = b
+			n++
+		}
+	}
+	backups = backups[:n]
+
+	if len(backups) <= rw.config.MaxBackups {
+		return
+	}
+	for _, b := range backups[:len(backups)-rw.config.MaxBackups] {
+		os.Remove(b.base)
+		os.Remove(b.base + compressSuffix)
+	}
+}
+
+// removeOversized 所有轮转文件与currentPath的总大小超过MaxTotalSize时, 从最旧的备份开始删除直到不超过上限, 调用约束同removeOldBackups
+//
+// 带时间戳的备份(含同一时间窗口内按大小滚动的文件)按时间戳和序号排在前面, 按序号滚动的备份(app.1.log)随后按序号从大到小排列;
+// 正在写入的文件及更新的(如预打开的)文件不会被删除
+func (rw *RotateWriter) removeOversized(currentPath string) {
+	if rw.config.MaxTotalSize <= 0 {
+		return
+	}
+
+	var paths []string
+	if rw.timeRotationEnabled() {
+		backups, err := rw.listBackups()
+		if err != nil {
+			return
+		}
+		var current time.Time
+		for _, b := range backups {
+			if b.base == currentPath {
+				current = b.stamp
+			}
+		}
+		for _, b := range backups {
+			if b.base != currentPath && (current.IsZero() || b.stamp.Before(current) || (b.stamp.Equal(current) && b.index > 0)) {
+				paths = append(paths, b.base)
+			}
+		}
+	}
+	last := 0
+	for rw.indexedExists(last + 1) {
+		last++
+	}
+	for i := last; i >= 1; i-- {
+		paths = append(paths, rw.indexedPath(i))
+	}
+
+	sizes := make([]int64, len(paths))
+	total := fileSize(currentPath)
+	for i, path := range paths {
+		sizes[i] = fileSize(path) + fileSize(path+compressSuffix)
+		total += sizes[i]
+	}
+
+	limit := rw.config.MaxTotalSize * 1024 * 1024
+	for i := 0; i < len(paths) && total > limit; i++ {
+		os.Remove(paths[i])
+		os.Remove(paths[i] + compressSuffix)
+		total -= sizes[i]
+	}
+}
+
+// fileSize 文件大小, 文件不存在时返回0
+func fileSize(path string) int64 {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return stat.Size()
+}
+
+// indexedPath 获取按序号滚动的第index个备份文件路径, 如app.1.log
+func (rw *RotateWriter) indexedPath(index int) string {
+	return fmt.Sprintf("%s.%d%s", rw.filePrefix, index, rw.fileExt)
+}
+
+// indexedExists 第index个备份文件(含已压缩文件)是否存在
+func (rw *RotateWriter) indexedExists(index int) bool {
+	path := rw.indexedPath(index)
+	for _, p := range []string{path, path + compressSuffix} {
+		if _, err := os.Stat(p); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// rollIndexed 按序号滚动: 依次后移已有备份(app.1→app.2), 将当前文件重命名为app.1并打开新的基础文件, 调用方需持有rw.mu
+func (rw *RotateWriter) rollIndexed() error {
+	if rw.file != nil {
+		rw.closeFile()
+		rw.file = nil
+	}
+	// 等待进行中的压缩, 避免压缩与重命名交错
+	rw.compressWg.Wait()
+
+	// 限制备份数时丢弃最后一个, 否则后移到第一个空闲序号
+	last := rw.config.MaxBackups
+	if last > 0 {
+		os.Remove(rw.indexedPath(last))
+		os.Remove(rw.indexedPath(last) + compressSuffix)
+	} else {
+		last = 1
+		for rw.indexedExists(last) {
+			last++
+		}
+	}
+	for i := last - 1; i >= 1; i-- {
+		for _, suffix := range []string{"", compressSuffix} {
+			os.Rename(rw.indexedPath(i)+suffix, rw.indexedPath(i+1)+suffix)
+		}
+	}
+
+	firstBackup := rw.indexedPath(1)
+	if err := os.Rename(rw.config.Filename, firstBackup); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if rw.config.Compress {
+		rw.compressWg.Add(1)
+		go func() {
+			defer rw.compressWg.Done()
+			rw.compressBackup(firstBackup)
+			rw.removeOversized(rw.config.Filename)
+		}()
+	}
+
+	if err := rw.openNewFile(); err != nil {
+		return err
+	}
+	rw.recordRotate(firstBackup, rw.config.Filename)
+	return nil
+}
+
+// rollSized 按时间戳命名时当前文件超过MaxSize: 将其重命名为同一时间窗口内的下一个序号文件(app_2026-01-07.1.log)
+// 并重新打开当前时间窗口的文件, 调用方需持有rw.mu
+func (rw *RotateWriter) rollSized() error {
+	path := rw.file.Name()
+	rw.closeFile()
+	rw.file = nil
+
+	prefix := strings.TrimSuffix(path, rw.fileExt)
+	index := 1
+	backup := fmt.Sprintf("%s.%d%s", prefix, index, rw.fileExt)
+	for fileExists(backup) || fileExists(backup+compressSuffix) {
+		index++
+		backup = fmt.Sprintf("%s.%d%s", prefix, index, rw.fileExt)
+	}
+	if err := os.Rename(path, backup); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if rw.config.Compress {
+		rw.compressWg.Add(1)
+		go func() {
+			defer rw.compressWg.Done()
+			rw.compressBackup(backup)
+			rw.cleanupBackups(path)
+		}()
+	}
+
+	// 直接打开, 保留预打开的下一个时间窗口的文件
+	file, err := rw.openFile(path)
+	if err != nil {
+		return err
+	}
+	rw.setFile(file)
+	rw.currentSize = 0
+	rw.afterSwitch(backup, path)
+	return nil
+}
+
+// fileExists 文件是否存在
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// rollRenamed 将基础文件重命名为stamp所在时间窗口的带时间戳备份并打开新的基础文件, 调用方需持有rw.mu
+//
+// 重命名在持有锁且关闭旧文件后、打开新文件前完成; 备份已存在时改为按序号滚动, 避免覆盖
+func (rw *RotateWriter) rollRenamed(stamp time.Time) error {
+	backup := rw.timestampedPath(stamp)
+	if fileExists(backup) || fileExists(backup+compressSuffix) {
+		return rw.rollIndexed()
+	}
+
+	if rw.file != nil {
+		rw.closeFile()
+		rw.file = nil
+	}
+	renamed, err := rw.renameToBackup(backup)
+	if err != nil {
+		return err
+	}
+
+	if err := rw.openNewFile(); err != nil {
+		return err
+	}
+	if renamed {
+		rw.recordRotate(backup, rw.config.Filename)
+	}
+	return nil
+}
+
+// renameStale 启动时基础文件的修改时间不在当前时间窗口内, 将其重命名为修改时间对应的备份
+func (rw *RotateWriter) renameStale() {
+	stat, err := os.Stat(rw.config.Filename)
+	if err != nil || stat.Size() == 0 {
+		return
+	}
+	modTime := stat.ModTime().In(rw.location())
+	if rw.formatStamp(modTime) == rw.formatStamp(rw.currentTime()) {
+		return
+	}
+	backup := rw.timestampedPath(modTime)
+	if _, err := os.Stat(backup); err == nil {
+		return
+	}
+	rw.renameToBackup(backup)
+}
+
+// renameToBackup 将基础文件重命名为backup并按配置压缩, 基础文件不存在时返回false
+func (rw *RotateWriter) renameToBackup(backup string) (bool, error) {
+	if err := os.Rename(rw.config.Filename, backup); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if rw.config.Compress {
+		rw.compressWg.Add(1)
+		go func() {
+			defer rw.compressWg.Done()
+			rw.compressBackup(backup)
+			rw.cleanupBackups(rw.config.Filename)
+		}()
+	}
+	return true, nil
+}
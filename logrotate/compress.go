@@ -0,0 +1,69 @@
+package logrotate
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+)
+
+// compressSuffix 压缩后备份文件的后缀
+const compressSuffix = ".gz"
+
+// compressFile 将path按level压缩为path.gz并删除原文件, 失败时保留原文件并删除临时文件
+func compressFile(path string, level int) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	// 压缩文件沿用原文件的权限
+	mode := os.FileMode(0644)
+	if stat, err := src.Stat(); err == nil {
+		mode = stat.Mode().Perm()
+	}
+
+	// 先写入临时文件再重命名, 避免读取到不完整的压缩文件
+	dstPath := path + compressSuffix
+	tmpPath := dstPath + ".tmp"
+	dst, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+
+	gw := newGzipWriter(dst, level)
+	_, err = io.Copy(gw, src)
+	if cerr := gw.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, dstPath)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	src.Close()
+	return os.Remove(path)
+}
+
+// compressBackup 压缩备份文件path, 失败时调用OnCompressError, 在后台压缩协程中调用
+func (rw *RotateWriter) compressBackup(path string) {
+	if err := compressFile(path, rw.config.CompressLevel); err != nil && rw.config.OnCompressError != nil {
+		rw.config.OnCompressError(path, err)
+	}
+}
+
+// newGzipWriter 按level创建gzip写入器, 0或无效的level使用gzip.DefaultCompression
+func newGzipWriter(w io.Writer, level int) *gzip.Writer {
+	if level != gzip.NoCompression {
+		if gw, err := gzip.NewWriterLevel(w, level); err == nil {
+			return gw
+		}
+	}
+	return gzip.NewWriter(w)
+}
@@ -0,0 +1,56 @@
+package logrotate
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// logReader 同时关闭解压器和底层文件
+type logReader struct {
+	io.Reader
+	closeFn func() error
+	file    *os.File
+}
+
+// Close 实现io.Closer接口
+func (r *logReader) Close() error {
+	var err error
+	if r.closeFn != nil {
+		err = r.closeFn()
+	}
+	if cerr := r.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// OpenLogReader 打开日志文件用于读取, .gz/.zst 备份文件会被透明解压
+func OpenLogReader(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case strings.HasSuffix(path, ".gz"):
+		gr, err := gzip.NewReader(file)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		return &logReader{Reader: gr, closeFn: gr.Close, file: file}, nil
+	case strings.HasSuffix(path, ".zst"):
+		zr, err := zstd.NewReader(file)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		return &logReader{Reader: zr, closeFn: func() error { zr.Close(); return nil }, file: file}, nil
+	default:
+		return file, nil
+	}
+}
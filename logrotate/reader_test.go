@@ -0,0 +1,59 @@
+package logrotate
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestOpenLogReader(t *testing.T) {
+	dir := t.TempDir()
+	content := []byte("line1\nline2\n")
+
+	plain := filepath.Join(dir, "app_2026-01-01.log")
+	if err := os.WriteFile(plain, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	gzPath := filepath.Join(dir, "app_2026-01-02.log.gz")
+	gzFile, err := os.Create(gzPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gw := gzip.NewWriter(gzFile)
+	gw.Write(content)
+	gw.Close()
+	gzFile.Close()
+
+	zstPath := filepath.Join(dir, "app_2026-01-03.log.zst")
+	zstFile, err := os.Create(zstPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw, err := zstd.NewWriter(zstFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw.Write(content)
+	zw.Close()
+	zstFile.Close()
+
+	for _, path := range []string{plain, gzPath, zstPath} {
+		r, err := OpenLogReader(path)
+		if err != nil {
+			t.Fatalf("OpenLogReader(%s) failed: %v", path, err)
+		}
+		data, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatalf("read %s failed: %v", path, err)
+		}
+		if string(data) != string(content) {
+			t.Errorf("%s: expected %q, got %q", path, content, data)
+		}
+	}
+}
@@ -2,7 +2,9 @@
 package logrotate
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -13,18 +15,66 @@ import (
 // RotateConfig 定义轮转配置
 type RotateConfig struct {
 	// 时间轮转配置
-	TimeRotation string // "daily", "hourly", "minutely"
+	TimeRotation string // "monthly", "weekly"(ISO周, 文件名如app_2026-W02.log), "daily", "hourly", "minutely", 为空时不按时间轮转, 超过MaxSize时按序号滚动(app.log→app.1.log)
+	TimeFormat   string // 文件名中的时间戳格式, 如"20060102", 为空时按TimeRotation使用默认格式; 只影响文件名, 不影响轮转边界
+
+	// RotateStyle 文件命名方式, 为空或"timestamp"时活动文件名带时间戳; "rename"时始终写入Filename,
+	// 到达时间边界时将其重命名为带时间戳的备份(备份已存在时按序号滚动), 按大小或手动轮转时按序号滚动(app.log→app.1.log)
+	// rename方式适用于filebeat等要求活动文件名固定的采集工具
+	RotateStyle string
 
 	// 大小轮转配置
 	MaxSize    int64 // MB
-	MaxBackups int   // 最大备份文件数
+	MaxBackups int   // 最大备份文件数, 轮转后删除超出数量的最旧文件(含已压缩文件), 0表示不限制
 	MaxAge     int   // 保留天数
-	Compress   bool  // 是否压缩 (暂时不实现压缩功能)
+	Compress   bool  // 是否在轮转后将旧文件压缩为.gz
+	// MaxTotalSize 所有轮转文件(含正在写入的文件和已压缩文件)的总大小上限(MB), 轮转后从最旧的备份开始删除直到不超过上限, 0表示不限制
+	// 两次轮转之间正在写入的文件继续增长, 总大小最多暂时超出一个文件的大小
+	MaxTotalSize int64
+	// CompressLevel gzip压缩级别(gzip.HuffmanOnly~gzip.BestCompression), 0或无效值使用gzip.DefaultCompression
+	CompressLevel int
 
 	// 基础配置
-	Filename string // 基础文件名
+	Filename string      // 基础文件名
+	FileMode os.FileMode // 新建日志文件的权限, 0时使用0644; 压缩文件沿用原文件的权限
+	DirMode  os.FileMode // 新建日志目录的权限, 0时使用0755
+
+	// Preopen 在时间边界前由后台协程预先打开下一个时间窗口的文件, 到达边界时直接切换, 避免边界后首次写入的打开文件延迟
+	// 预打开由临近边界时的写入触发, 边界前的数据不会写入预打开的文件
+	Preopen bool
+
+	// SymlinkName 指向当前活动文件的符号链接, 便于tail -F等工具跟随固定路径; 不含目录时创建在日志文件所在目录
+	// 创建失败(如Windows无权限)时忽略, 不影响写入
+	SymlinkName string
+
+	// Location 文件名时间戳和轮转边界使用的时区, 为nil时使用本地时区; 服务器统一按UTC轮转时设为time.UTC
+	Location *time.Location
+
+	// OnRotate 每次切换文件成功后调用, 参数为旧文件和新文件路径; 在不持有锁的情况下调用, 可用于上传旧文件等
+	OnRotate func(oldPath, newPath string)
+
+	// BufferSize 大于0时使用该大小(字节)的缓冲区写入文件, 减少持锁期间的系统调用
+	// 缓冲数据按FlushInterval定期刷新, Sync/Close/轮转时全部刷新
+	BufferSize int
+	// FlushInterval 缓冲模式下的定期刷新间隔, 默认1秒
+	FlushInterval time.Duration
+
+	// FallbackThreshold 大于0时, 文件连续写入失败(如磁盘已满ENOSPC, 包括轮转时无法打开新文件)达到该次数后改为写入标准错误并返回成功,
+	// 每次写入仍先尝试文件, 成功后恢复写入文件; 缓冲模式下失败时缓冲区中未写入的数据会丢弃
+	FallbackThreshold int
+	// OnWriteError 每次写入文件失败后调用, 参数为错误和连续失败次数; 在不持有锁的情况下调用, 可用于告警计数
+	OnWriteError func(err error, failures int)
+	// OnCompressError 后台压缩备份文件失败时调用, 参数为备份文件路径和错误; 失败时保留未压缩的原文件,
+	// 在后台压缩协程中调用, 可用于告警
+	OnCompressError func(path string, err error)
 }
 
+// defaultFlushInterval 缓冲模式默认的刷新间隔
+const defaultFlushInterval = time.Second
+
+// preopenLead 开启Preopen时, 距离时间边界多久开始预打开下一个文件
+const preopenLead = 5 * time.Second
+
 // RotateWriter 实现io.WriteCloser接口，支持轮转
 type RotateWriter struct {
 	config      RotateConfig
@@ -36,10 +86,36 @@ type RotateWriter struct {
 	lastRotateTime time.Time
 	filePrefix     string
 	fileExt        string
+
+	// 用于预打开下一个时间窗口的文件
+	nextFile   *os.File
+	preopening bool
+
+	now func() time.Time // 当前时间, 测试时可替换
+
+	// 用于等待后台压缩完成
+	compressWg sync.WaitGroup
+
+	// 待执行的轮转回调, 每项为{旧路径, 新路径}
+	pendingRotates [][2]string
+
+	// 用于缓冲写入
+	buf       *bufio.Writer
+	flushStop chan struct{}
+
+	// 用于写入失败时降级
+	fallback        io.Writer // 备用输出, 默认标准错误, 测试时可替换
+	failures        int       // 连续写入失败次数
+	pendingWriteErr error     // 待回调的写入错误
 }
 
 // NewRotateWriter 创建新的轮转写入器
 func NewRotateWriter(config RotateConfig) (*RotateWriter, error) {
+	return newRotateWriter(config, time.Now)
+}
+
+// newRotateWriter 使用指定时钟创建轮转写入器
+func newRotateWriter(config RotateConfig, now func() time.Time) (*RotateWriter, error) {
 	// 解析文件名获取前缀和扩展名
 	ext := filepath.Ext(config.Filename)
 	prefix := strings.TrimSuffix(config.Filename, ext)
@@ -48,6 +124,16 @@ func NewRotateWriter(config RotateConfig) (*RotateWriter, error) {
 		config:     config,
 		filePrefix: prefix,
 		fileExt:    ext,
+		now:        now,
+		fallback:   os.Stderr,
+	}
+	if config.BufferSize > 0 {
+		rw.buf = bufio.NewWriterSize(nil, config.BufferSize)
+	}
+
+	// 上次运行遗留的其他时间窗口的文件先重命名为备份
+	if rw.renameStyle() && rw.timeRotationEnabled() {
+		rw.renameStale()
 	}
 
 	// 打开初始文件
@@ -59,32 +145,103 @@ func NewRotateWriter(config RotateConfig) (*RotateWriter, error) {
 	// 设置初始轮转时间
 	rw.lastRotateTime = rw.getRotationTimeBoundary()
 
+	if rw.buf != nil {
+		rw.flushStop = make(chan struct{})
+		go rw.flushLoop(rw.flushStop)
+	}
+
 	return rw, nil
 }
 
-// openNewFile 打开新文件
-func (rw *RotateWriter) openNewFile() error {
-	// 如果当前文件已打开，先关闭
-	if rw.file != nil {
-		rw.file.Close()
+// flushLoop 定期刷新缓冲区, 直到stop关闭
+func (rw *RotateWriter) flushLoop(stop chan struct{}) {
+	interval := rw.config.FlushInterval
+	if interval <= 0 {
+		interval = defaultFlushInterval
 	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			rw.mu.Lock()
+			rw.flush()
+			rw.mu.Unlock()
+		}
+	}
+}
+
+// flush 将缓冲数据写入当前文件, 调用方需持有rw.mu
+func (rw *RotateWriter) flush() error {
+	if rw.buf == nil || rw.file == nil {
+		return nil
+	}
+	return rw.buf.Flush()
+}
+
+// closeFile 刷新缓冲区并关闭当前文件, 调用方需持有rw.mu
+func (rw *RotateWriter) closeFile() error {
+	err := rw.flush()
+	if cerr := rw.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
 
+// setFile 切换当前文件, 缓冲区改为写入新文件, 调用方需持有rw.mu
+func (rw *RotateWriter) setFile(file *os.File) {
+	rw.file = file
+	if rw.buf != nil {
+		rw.buf.Reset(file)
+	}
+}
+
+// openNewFile 打开新文件
+func (rw *RotateWriter) openNewFile() error {
 	// 获取当前时间的文件路径
 	currentPath := rw.getCurrentFilePath()
 
-	// 确保目录存在
-	dir := filepath.Dir(currentPath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return err
+	// 如果当前文件已打开，先关闭, 文件名变化时压缩旧文件
+	var oldPath string
+	if rw.file != nil {
+		oldPath = rw.file.Name()
+		rw.closeFile()
+		if rw.config.Compress && oldPath != currentPath {
+			rw.compressWg.Add(1)
+			go func() {
+				defer rw.compressWg.Done()
+				rw.compressBackup(oldPath)
+				// 压缩期间清理可能与压缩交错, 压缩完成后再清理一次
+				rw.cleanupBackups(currentPath)
+			}()
+		}
 	}
 
-	// 打开文件
-	file, err := os.OpenFile(currentPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	// 优先使用预打开的文件
+	if rw.nextFile != nil {
+		nextFile := rw.nextFile
+		rw.nextFile = nil
+		if nextFile.Name() == currentPath {
+			rw.setFile(nextFile)
+			rw.currentSize = 0
+			if stat, err := nextFile.Stat(); err == nil {
+				rw.currentSize = stat.Size()
+			}
+			rw.afterSwitch(oldPath, currentPath)
+			return nil
+		}
+		nextFile.Close()
+	}
+
+	file, err := rw.openFile(currentPath)
 	if err != nil {
 		return err
 	}
 
-	rw.file = file
+	rw.setFile(file)
 
 	// 获取文件大小
 	stat, err := file.Stat()
@@ -94,30 +251,142 @@ func (rw *RotateWriter) openNewFile() error {
 		rw.currentSize = stat.Size()
 	}
 
+	rw.afterSwitch(oldPath, currentPath)
 	return nil
 }
 
+// afterSwitch 切换到新文件后更新符号链接、清理旧备份并登记轮转回调, oldPath为空表示首次打开
+func (rw *RotateWriter) afterSwitch(oldPath, newPath string) {
+	rw.updateSymlink()
+	rw.cleanupBackups(newPath)
+	if oldPath != "" {
+		rw.recordRotate(oldPath, newPath)
+	}
+}
+
+// recordRotate 登记一次轮转, 回调在释放rw.mu后由unlockAndNotify执行
+func (rw *RotateWriter) recordRotate(oldPath, newPath string) {
+	if rw.config.OnRotate != nil {
+		rw.pendingRotates = append(rw.pendingRotates, [2]string{oldPath, newPath})
+	}
+}
+
+// unlockAndNotify 释放rw.mu后执行登记的轮转回调和写入错误回调, 回调中可以安全调用GetLogFilePath等方法
+func (rw *RotateWriter) unlockAndNotify() {
+	pending := rw.pendingRotates
+	rw.pendingRotates = nil
+	writeErr, failures := rw.pendingWriteErr, rw.failures
+	rw.pendingWriteErr = nil
+	rw.mu.Unlock()
+
+	for _, r := range pending {
+		rw.config.OnRotate(r[0], r[1])
+	}
+	if writeErr != nil {
+		rw.config.OnWriteError(writeErr, failures)
+	}
+}
+
+// updateSymlink 将SymlinkName指向当前文件, 先创建临时链接再重命名以原子替换
+func (rw *RotateWriter) updateSymlink() {
+	if rw.config.SymlinkName == "" || rw.file == nil {
+		return
+	}
+
+	target := rw.file.Name()
+	link := rw.config.SymlinkName
+	if filepath.Base(link) == link {
+		link = filepath.Join(filepath.Dir(target), link)
+	}
+	if filepath.Clean(link) == filepath.Clean(target) {
+		return
+	}
+
+	// 链接与文件同目录时使用相对路径, 目录整体移动后仍然有效
+	if filepath.Dir(link) == filepath.Dir(target) {
+		target = filepath.Base(target)
+	} else if abs, err := filepath.Abs(target); err == nil {
+		target = abs
+	}
+
+	tmp := link + ".tmp"
+	os.Remove(tmp)
+	if err := os.Symlink(target, tmp); err != nil {
+		return
+	}
+	if err := os.Rename(tmp, link); err != nil {
+		os.Remove(tmp)
+	}
+}
+
+// openFile 创建目录并以追加方式打开文件
+func (rw *RotateWriter) openFile(path string) (*os.File, error) {
+	// 确保目录存在
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, rw.dirMode()); err != nil {
+		return nil, err
+	}
+
+	// 打开文件
+	return os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, rw.fileMode())
+}
+
+// fileMode 新建日志文件的权限
+func (rw *RotateWriter) fileMode() os.FileMode {
+	if rw.config.FileMode != 0 {
+		return rw.config.FileMode
+	}
+	return 0644
+}
+
+// dirMode 新建日志目录的权限
+func (rw *RotateWriter) dirMode() os.FileMode {
+	if rw.config.DirMode != 0 {
+		return rw.config.DirMode
+	}
+	return 0755
+}
+
+// location 获取轮转使用的时区
+func (rw *RotateWriter) location() *time.Location {
+	if rw.config.Location != nil {
+		return rw.config.Location
+	}
+	return time.Local
+}
+
+// currentTime 获取轮转时区下的当前时间
+func (rw *RotateWriter) currentTime() time.Time {
+	return rw.now().In(rw.location())
+}
+
 // getCurrentFilePath 获取当前时间对应的文件路径
 func (rw *RotateWriter) getCurrentFilePath() string {
-	now := time.Now()
+	return rw.getFilePathAt(rw.currentTime())
+}
 
-	var timePart string
-	switch rw.config.TimeRotation {
-	case "hourly":
-		timePart = now.Format("2006-01-02_15") // 年-月-日_时
-	case "minutely":
-		timePart = now.Format("2006-01-02_15_04") // 年-月-日_时_分
-	default: // daily
-		timePart = now.Format("2006-01-02") // 年-月-日
+// getFilePathAt 获取指定时间对应的文件路径
+func (rw *RotateWriter) getFilePathAt(now time.Time) string {
+	// 不按时间轮转或rename方式时始终写入基础文件名
+	if !rw.timeRotationEnabled() || rw.renameStyle() {
+		return rw.config.Filename
 	}
+	return rw.timestampedPath(now)
+}
 
-	return fmt.Sprintf("%s_%s%s", rw.filePrefix, timePart, rw.fileExt)
+// timestampedPath 获取指定时间对应的带时间戳文件路径
+func (rw *RotateWriter) timestampedPath(t time.Time) string {
+	return fmt.Sprintf("%s_%s%s", rw.filePrefix, rw.formatStamp(t), rw.fileExt)
 }
 
 // getRotationTimeBoundary 获取下一个轮转时间边界
 func (rw *RotateWriter) getRotationTimeBoundary() time.Time {
-	now := time.Now()
+	now := rw.currentTime()
 	switch rw.config.TimeRotation {
+	case "monthly":
+		return time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, now.Location())
+	case "weekly": // 下周一零点
+		return time.Date(now.Year(), now.Month(), now.Day()+7-(int(now.Weekday())+6)%7, 0, 0, 0, 0, now.Location())
 	case "hourly":
 		return time.Date(now.Year(), now.Month(), now.Day(), now.Hour()+1, 0, 0, 0, now.Location())
 	case "minutely":
@@ -127,70 +396,168 @@ func (rw *RotateWriter) getRotationTimeBoundary() time.Time {
 	}
 }
 
-// checkRotate 检查是否需要轮转
-func (rw *RotateWriter) checkRotate() error {
-	now := time.Now()
+// timeRotationEnabled 是否按时间轮转
+func (rw *RotateWriter) timeRotationEnabled() bool {
+	return rw.config.TimeRotation != ""
+}
+
+// renameStyle 是否始终写入基础文件名, 轮转时重命名为备份
+func (rw *RotateWriter) renameStyle() bool {
+	return rw.config.RotateStyle == "rename"
+}
+
+// checkRotate 检查是否需要轮转, n为即将写入的字节数
+//
+// 每次写入都会依次检查时间和大小: 先切换到当前时间窗口的文件, 再检查该文件写入n字节后是否超过MaxSize;
+// 文件已有内容且写入后超过MaxSize时先滚动, 单条超过MaxSize的日志仍完整写入新文件
+func (rw *RotateWriter) checkRotate(n int) error {
+	now := rw.currentTime()
+	rw.checkPreopen(now)
 
 	// 检查是否需要按时间轮转
-	if now.After(rw.lastRotateTime) {
-		currentPath := rw.getCurrentFilePath()
-		if rw.file == nil || rw.file.Name() != currentPath {
+	if rw.timeRotationEnabled() && now.After(rw.lastRotateTime) {
+		if rw.renameStyle() {
+			// 边界前的时刻属于刚结束的时间窗口
+			if err := rw.rollRenamed(rw.lastRotateTime.Add(-time.Nanosecond)); err != nil {
+				return err
+			}
+		} else if rw.file == nil || rw.file.Name() != rw.getCurrentFilePath() {
 			if err := rw.openNewFile(); err != nil {
 				return err
 			}
-			rw.lastRotateTime = rw.getRotationTimeBoundary()
 		}
-		return nil
+		rw.lastRotateTime = rw.getRotationTimeBoundary()
 	}
 
-	// 检查是否需要按大小轮转
-	maxSizeBytes := rw.config.MaxSize * 1024 * 1024 // 转换为字节
-	if maxSizeBytes > 0 && rw.currentSize >= maxSizeBytes {
+	// 文件已关闭或上次滚动打开失败时重新打开
+	if rw.file == nil {
 		if err := rw.openNewFile(); err != nil {
 			return err
 		}
 	}
 
+	// 检查是否需要按大小轮转
+	maxSizeBytes := rw.config.MaxSize * 1024 * 1024 // 转换为字节
+	if maxSizeBytes > 0 && rw.currentSize > 0 && rw.currentSize+int64(n) > maxSizeBytes {
+		if !rw.timeRotationEnabled() || rw.renameStyle() {
+			return rw.rollIndexed()
+		}
+		return rw.rollSized()
+	}
+
 	return nil
 }
 
+// checkPreopen 临近时间边界时在后台预打开下一个时间窗口的文件, 调用方需持有rw.mu
+func (rw *RotateWriter) checkPreopen(now time.Time) {
+	if !rw.config.Preopen || !rw.timeRotationEnabled() || rw.preopening || rw.nextFile != nil {
+		return
+	}
+	if now.After(rw.lastRotateTime) || rw.lastRotateTime.Sub(now) > preopenLead {
+		return
+	}
+
+	// 边界时刻属于下一个时间窗口
+	nextPath := rw.getFilePathAt(rw.lastRotateTime)
+	if rw.file != nil && rw.file.Name() == nextPath {
+		return
+	}
+	rw.preopening = true
+	go func() {
+		file, err := rw.openFile(nextPath)
+
+		rw.mu.Lock()
+		defer rw.mu.Unlock()
+		rw.preopening = false
+		if err != nil {
+			return
+		}
+		// 写入器已关闭
+		if rw.file == nil {
+			file.Close()
+			return
+		}
+		rw.nextFile = file
+	}()
+}
+
 // Write 实现io.Writer接口
 func (rw *RotateWriter) Write(p []byte) (n int, err error) {
 	rw.mu.Lock()
-	defer rw.mu.Unlock()
+	defer rw.unlockAndNotify()
 
-	// 检查是否需要轮转
-	if err := rw.checkRotate(); err != nil {
-		return 0, err
+	// 检查是否需要轮转, 轮转时打开新文件失败(如磁盘已满)同样计为写入失败
+	if err := rw.checkRotate(len(p)); err != nil {
+		return rw.writeFailed(p, 0, err)
 	}
 
 	// 写入数据
-	n, err = rw.file.Write(p)
-	if err == nil {
+	if rw.buf != nil {
+		n, err = rw.buf.Write(p)
+	} else {
+		n, err = rw.file.Write(p)
+	}
+	if err != nil {
+		if rw.buf != nil {
+			// bufio.Writer出错后不再接受写入, 丢弃未写入的数据以便恢复后继续写入
+			rw.buf.Reset(rw.file)
+			n = 0
+		}
 		rw.currentSize += int64(n)
+		return rw.writeFailed(p, n, err)
 	}
+	rw.currentSize += int64(n)
+	rw.failures = 0
 
 	return n, err
 }
 
+// writeFailed 处理写入文件失败, n为已写入文件的字节数; 连续失败达到FallbackThreshold后将其余数据改写备用输出, 调用方需持有rw.mu
+func (rw *RotateWriter) writeFailed(p []byte, n int, err error) (int, error) {
+	rw.failures++
+	if rw.config.OnWriteError != nil {
+		rw.pendingWriteErr = err
+	}
+
+	if rw.config.FallbackThreshold <= 0 || rw.failures < rw.config.FallbackThreshold {
+		return n, err
+	}
+	if _, ferr := rw.fallback.Write(p[n:]); ferr != nil {
+		return n, err
+	}
+	return len(p), nil
+}
+
 // Sync 同步文件到磁盘
 func (rw *RotateWriter) Sync() error {
 	rw.mu.Lock()
 	defer rw.mu.Unlock()
 
 	if rw.file != nil {
+		if err := rw.flush(); err != nil {
+			return err
+		}
 		return rw.file.Sync()
 	}
 	return nil
 }
 
-// Close 关闭写入器
+// Close 关闭写入器, 并等待进行中的压缩完成
 func (rw *RotateWriter) Close() error {
 	rw.mu.Lock()
 	defer rw.mu.Unlock()
+	defer rw.compressWg.Wait()
 
+	if rw.flushStop != nil {
+		close(rw.flushStop)
+		rw.flushStop = nil
+	}
+	if rw.nextFile != nil {
+		rw.nextFile.Close()
+		rw.nextFile = nil
+	}
 	if rw.file != nil {
-		err := rw.file.Close()
+		err := rw.closeFile()
 		rw.file = nil
 		return err
 	}
@@ -200,11 +567,49 @@ func (rw *RotateWriter) Close() error {
 // Rotate 手动触发轮转
 func (rw *RotateWriter) Rotate() error {
 	rw.mu.Lock()
-	defer rw.mu.Unlock()
+	defer rw.unlockAndNotify()
 
+	if !rw.timeRotationEnabled() || rw.renameStyle() {
+		return rw.rollIndexed()
+	}
 	return rw.openNewFile()
 }
 
+// Reopen 关闭并重新打开当前路径的文件, 不切换到新的文件名, 用于配合外部logrotate(8)等工具
+//
+// 外部工具重命名文件后, 写入器仍在写入旧的inode, 调用Reopen后在原路径创建新文件; 通常在收到SIGHUP时调用:
+//
+//	ch := make(chan os.Signal, 1)
+//	signal.Notify(ch, syscall.SIGHUP)
+//	go func() {
+//		for range ch {
+//			rw.Reopen()
+//		}
+//	}()
+func (rw *RotateWriter) Reopen() error {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	if rw.file == nil {
+		return nil
+	}
+	path := rw.file.Name()
+	err := rw.closeFile()
+	rw.file = nil
+
+	file, oerr := rw.openFile(path)
+	if oerr != nil {
+		return oerr
+	}
+	rw.setFile(file)
+	rw.currentSize = 0
+	if stat, serr := file.Stat(); serr == nil {
+		rw.currentSize = stat.Size()
+	}
+	rw.updateSymlink()
+	return err
+}
+
 // GetLogFilePath 获取当前日志文件路径
 func (rw *RotateWriter) GetLogFilePath() string {
 	rw.mu.Lock()
@@ -215,3 +620,22 @@ func (rw *RotateWriter) GetLogFilePath() string {
 	}
 	return ""
 }
+
+// CurrentSize 获取当前文件已写入的字节数(含未刷新的缓冲数据)
+func (rw *RotateWriter) CurrentSize() int64 {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	return rw.currentSize
+}
+
+// NextRotation 获取下一次按时间轮转的时间边界, 不按时间轮转时返回零值
+func (rw *RotateWriter) NextRotation() time.Time {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	if !rw.timeRotationEnabled() {
+		return time.Time{}
+	}
+	return rw.lastRotateTime
+}
@@ -0,0 +1,973 @@
+package logrotate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// fakeClock 可手动调整的时钟
+type fakeClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = t
+}
+
+// readFile 读取文件内容, 文件不存在时返回空字符串
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatalf("read %s failed: %v", path, err)
+	}
+	return string(data)
+}
+
+func TestRotateWriterPreopen(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{t: time.Date(2026, 1, 7, 23, 59, 50, 0, time.Local)}
+
+	rw, err := newRotateWriter(RotateConfig{
+		TimeRotation: "daily",
+		Filename:     filepath.Join(dir, "app.log"),
+		Preopen:      true,
+	}, clock.Now)
+	if err != nil {
+		t.Fatalf("newRotateWriter failed: %v", err)
+	}
+	defer rw.Close()
+
+	today := filepath.Join(dir, "app_2026-01-07.log")
+	tomorrow := filepath.Join(dir, "app_2026-01-08.log")
+
+	// 进入预打开窗口, 触发后台预打开
+	clock.Set(time.Date(2026, 1, 7, 23, 59, 58, 0, time.Local))
+	rw.Write([]byte("before-1\n"))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		rw.mu.Lock()
+		ready := rw.nextFile != nil
+		rw.mu.Unlock()
+		if ready || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	rw.mu.Lock()
+	nextFile := rw.nextFile
+	rw.mu.Unlock()
+	if nextFile == nil || nextFile.Name() != tomorrow {
+		t.Fatalf("expected next file %s to be preopened", tomorrow)
+	}
+
+	// 边界前的写入仍然写入当前文件
+	rw.Write([]byte("before-2\n"))
+	if got := rw.GetLogFilePath(); got != today {
+		t.Errorf("expected current file %s before boundary, got %s", today, got)
+	}
+
+	// 到达边界后切换到预打开的文件
+	clock.Set(time.Date(2026, 1, 8, 0, 0, 0, 1, time.Local))
+	rw.Write([]byte("after-1\n"))
+	rw.mu.Lock()
+	swapped := rw.file == nextFile
+	rw.mu.Unlock()
+	if !swapped {
+		t.Error("expected the preopened file to be swapped in at the boundary")
+	}
+
+	if got := readFile(t, today); got != "before-1\nbefore-2\n" {
+		t.Errorf("unexpected content in %s: %q", today, got)
+	}
+	if got := readFile(t, tomorrow); got != "after-1\n" {
+		t.Errorf("unexpected content in %s: %q", tomorrow, got)
+	}
+}
+
+func TestCompressLevel(t *testing.T) {
+	dir := t.TempDir()
+
+	// 可压缩但不平凡的固定内容
+	var payload bytes.Buffer
+	seed := uint32(1)
+	for i := 0; i < 20000; i++ {
+		seed = seed*1664525 + 1013904223
+		fmt.Fprintf(&payload, "req=%d status=%d path=/api/v%d\n", seed%5000, 200+seed%5, seed%3)
+	}
+
+	compressedSize := func(name string, level int) int64 {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, payload.Bytes(), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+		if err := compressFile(path, level); err != nil {
+			t.Fatalf("compressFile(level %d) failed: %v", level, err)
+		}
+		stat, err := os.Stat(path + compressSuffix)
+		if err != nil {
+			t.Fatalf("Stat failed: %v", err)
+		}
+		return stat.Size()
+	}
+
+	fast, best := compressedSize("fast.log", 1), compressedSize("best.log", 9)
+	if best >= fast {
+		t.Errorf("expected level 9 (%d bytes) to be smaller than level 1 (%d bytes)", best, fast)
+	}
+
+	// 无效级别回退到默认级别
+	if got, want := compressedSize("invalid.log", 42), compressedSize("default.log", 0); got != want {
+		t.Errorf("expected invalid level to use default compression, got %d bytes want %d", got, want)
+	}
+}
+
+func TestRotateWriterCompress(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{t: time.Date(2026, 1, 7, 10, 0, 0, 0, time.Local)}
+
+	rw, err := newRotateWriter(RotateConfig{
+		TimeRotation: "hourly",
+		Filename:     filepath.Join(dir, "app.log"),
+		Compress:     true,
+	}, clock.Now)
+	if err != nil {
+		t.Fatalf("newRotateWriter failed: %v", err)
+	}
+
+	rw.Write([]byte("hour-10\n"))
+	clock.Set(time.Date(2026, 1, 7, 11, 0, 1, 0, time.Local))
+	rw.Write([]byte("hour-11\n"))
+	if err := rw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	old := filepath.Join(dir, "app_2026-01-07_10.log")
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be removed after compression", old)
+	}
+	r, err := OpenLogReader(old + ".gz")
+	if err != nil {
+		t.Fatalf("OpenLogReader failed: %v", err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil || string(data) != "hour-10\n" {
+		t.Errorf("unexpected compressed content %q: %v", data, err)
+	}
+
+	// 当前文件不压缩
+	if got := readFile(t, filepath.Join(dir, "app_2026-01-07_11.log")); got != "hour-11\n" {
+		t.Errorf("unexpected content in current file: %q", got)
+	}
+}
+
+func TestRotateWriterCompressError(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{t: time.Date(2026, 1, 7, 10, 0, 0, 0, time.Local)}
+
+	var mu sync.Mutex
+	var failed []string
+	rw, err := newRotateWriter(RotateConfig{
+		TimeRotation: "hourly",
+		Filename:     filepath.Join(dir, "app.log"),
+		Compress:     true,
+		OnCompressError: func(path string, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			failed = append(failed, path)
+		},
+	}, clock.Now)
+	if err != nil {
+		t.Fatalf("newRotateWriter failed: %v", err)
+	}
+
+	// 压缩目标路径被目录占用, 重命名临时文件失败
+	old := filepath.Join(dir, "app_2026-01-07_10.log")
+	if err := os.Mkdir(old+compressSuffix, 0755); err != nil {
+		t.Fatalf("Mkdir failed: %v", err)
+	}
+
+	rw.Write([]byte("hour-10\n"))
+	clock.Set(time.Date(2026, 1, 7, 11, 0, 1, 0, time.Local))
+	rw.Write([]byte("hour-11\n"))
+	if err := rw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(failed) != 1 || failed[0] != old {
+		t.Errorf("expected compress error for %s, got %v", old, failed)
+	}
+	if got := readFile(t, old); got != "hour-10\n" {
+		t.Errorf("expected original file to be kept, got %q", got)
+	}
+	if _, err := os.Stat(old + compressSuffix + ".tmp"); !os.IsNotExist(err) {
+		t.Error("expected temporary compressed file to be removed")
+	}
+}
+
+func TestRotateWriterMaxBackups(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{t: time.Date(2026, 1, 7, 10, 0, 0, 0, time.Local)}
+
+	// 无关文件不受清理影响
+	unrelated := []string{"other_2026-01-01_00.log", "app_notes.log", "app_2026-01-01_00.txt"}
+	for _, name := range unrelated {
+		os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644)
+	}
+
+	rw, err := newRotateWriter(RotateConfig{
+		TimeRotation: "hourly",
+		Filename:     filepath.Join(dir, "app.log"),
+		MaxBackups:   2,
+	}, clock.Now)
+	if err != nil {
+		t.Fatalf("newRotateWriter failed: %v", err)
+	}
+	defer rw.Close()
+
+	for h := 10; h < 15; h++ {
+		clock.Set(time.Date(2026, 1, 7, h, 0, 1, 0, time.Local))
+		rw.Write([]byte("line\n"))
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(dir, "app_2026-01-07_*.log"))
+	want := []string{
+		filepath.Join(dir, "app_2026-01-07_12.log"),
+		filepath.Join(dir, "app_2026-01-07_13.log"),
+		filepath.Join(dir, "app_2026-01-07_14.log"),
+	}
+	if len(matches) != len(want) {
+		t.Fatalf("expected files %v, got %v", want, matches)
+	}
+	for i := range want {
+		if matches[i] != want[i] {
+			t.Errorf("expected %s, got %s", want[i], matches[i])
+		}
+	}
+	for _, name := range unrelated {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("unrelated file %s should be kept: %v", name, err)
+		}
+	}
+}
+
+func TestRotateWriterIndexedRollover(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+
+	rw, err := NewRotateWriter(RotateConfig{
+		Filename:   filename,
+		MaxSize:    1,
+		MaxBackups: 2,
+	})
+	if err != nil {
+		t.Fatalf("NewRotateWriter failed: %v", err)
+	}
+	defer rw.Close()
+
+	// 每次写满1MB, 下一次写入触发滚动
+	for _, c := range "abcd" {
+		if _, err := rw.Write(bytes.Repeat([]byte{byte(c)}, 1024*1024)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	if got := rw.GetLogFilePath(); got != filename {
+		t.Errorf("expected active file %s, got %s", filename, got)
+	}
+	want := map[string]byte{
+		filename:                        'd',
+		filepath.Join(dir, "app.1.log"): 'c',
+		filepath.Join(dir, "app.2.log"): 'b',
+	}
+	for path, c := range want {
+		data := readFile(t, path)
+		if len(data) != 1024*1024 || data[0] != c {
+			t.Errorf("unexpected content in %s: len=%d", path, len(data))
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "app.3.log")); !os.IsNotExist(err) {
+		t.Error("expected backups beyond MaxBackups to be removed")
+	}
+}
+
+func TestRotateWriterSymlink(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{t: time.Date(2026, 1, 7, 10, 0, 0, 0, time.Local)}
+
+	rw, err := newRotateWriter(RotateConfig{
+		TimeRotation: "hourly",
+		Filename:     filepath.Join(dir, "app.log"),
+		SymlinkName:  "app.log",
+	}, clock.Now)
+	if err != nil {
+		t.Fatalf("newRotateWriter failed: %v", err)
+	}
+	defer rw.Close()
+
+	link := filepath.Join(dir, "app.log")
+	if target, err := os.Readlink(link); err != nil || target != "app_2026-01-07_10.log" {
+		t.Fatalf("unexpected symlink target %q: %v", target, err)
+	}
+
+	clock.Set(time.Date(2026, 1, 7, 11, 0, 1, 0, time.Local))
+	rw.Write([]byte("hour-11\n"))
+
+	if target, err := os.Readlink(link); err != nil || target != "app_2026-01-07_11.log" {
+		t.Errorf("unexpected symlink target after rotation %q: %v", target, err)
+	}
+	if got := rw.GetLogFilePath(); got != filepath.Join(dir, "app_2026-01-07_11.log") {
+		t.Errorf("GetLogFilePath should return the real file, got %s", got)
+	}
+	if got := readFile(t, link); got != "hour-11\n" {
+		t.Errorf("unexpected content through symlink: %q", got)
+	}
+}
+
+func TestRotateWriterLocation(t *testing.T) {
+	dir := t.TempDir()
+	// UTC 2026-01-07 20:00 在东八区已是 2026-01-08 04:00
+	clock := &fakeClock{t: time.Date(2026, 1, 7, 20, 0, 0, 0, time.UTC)}
+	loc := time.FixedZone("UTC+8", 8*3600)
+
+	rw, err := newRotateWriter(RotateConfig{
+		TimeRotation: "daily",
+		Filename:     filepath.Join(dir, "app.log"),
+		Location:     loc,
+	}, clock.Now)
+	if err != nil {
+		t.Fatalf("newRotateWriter failed: %v", err)
+	}
+	defer rw.Close()
+
+	if got, want := rw.GetLogFilePath(), filepath.Join(dir, "app_2026-01-08.log"); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+	// 边界为东八区午夜, 即UTC 2026-01-08 16:00
+	if want := time.Date(2026, 1, 8, 16, 0, 0, 0, time.UTC); !rw.lastRotateTime.Equal(want) {
+		t.Errorf("expected boundary %v, got %v", want, rw.lastRotateTime)
+	}
+
+	clock.Set(time.Date(2026, 1, 8, 16, 0, 1, 0, time.UTC))
+	rw.Write([]byte("next day\n"))
+	if got, want := rw.GetLogFilePath(), filepath.Join(dir, "app_2026-01-09.log"); got != want {
+		t.Errorf("expected %s after boundary, got %s", want, got)
+	}
+}
+
+func TestRotateWriterOnRotate(t *testing.T) {
+	dir := t.TempDir()
+
+	var rw *RotateWriter
+	var calls [][2]string
+	rw, err := NewRotateWriter(RotateConfig{
+		Filename: filepath.Join(dir, "app.log"),
+		OnRotate: func(oldPath, newPath string) {
+			// 回调中调用加锁方法不应死锁
+			if got := rw.GetLogFilePath(); got != newPath {
+				t.Errorf("expected current path %s in callback, got %s", newPath, got)
+			}
+			calls = append(calls, [2]string{oldPath, newPath})
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewRotateWriter failed: %v", err)
+	}
+	defer rw.Close()
+
+	for i := 0; i < 3; i++ {
+		if err := rw.Rotate(); err != nil {
+			t.Fatalf("Rotate failed: %v", err)
+		}
+	}
+
+	if len(calls) != 3 {
+		t.Fatalf("expected 3 callbacks, got %d", len(calls))
+	}
+	want := [2]string{filepath.Join(dir, "app.1.log"), filepath.Join(dir, "app.log")}
+	if calls[0] != want {
+		t.Errorf("expected %v, got %v", want, calls[0])
+	}
+}
+
+func TestRotateWriterTimeFormat(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{t: time.Date(2026, 1, 7, 23, 30, 0, 0, time.Local)}
+
+	rw, err := newRotateWriter(RotateConfig{
+		TimeRotation: "daily",
+		TimeFormat:   "20060102",
+		Filename:     filepath.Join(dir, "app.log"),
+		MaxBackups:   1,
+	}, clock.Now)
+	if err != nil {
+		t.Fatalf("newRotateWriter failed: %v", err)
+	}
+	defer rw.Close()
+
+	if got, want := rw.GetLogFilePath(), filepath.Join(dir, "app_20260107.log"); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+	// 边界仍按天计算
+	if want := time.Date(2026, 1, 8, 0, 0, 0, 0, time.Local); !rw.lastRotateTime.Equal(want) {
+		t.Errorf("expected boundary %v, got %v", want, rw.lastRotateTime)
+	}
+
+	for _, day := range []int{8, 9} {
+		clock.Set(time.Date(2026, 1, day, 0, 0, 1, 0, time.Local))
+		rw.Write([]byte("line\n"))
+	}
+	// 自定义格式的旧文件同样参与MaxBackups清理
+	matches, _ := filepath.Glob(filepath.Join(dir, "app_*.log"))
+	if len(matches) != 2 || matches[0] != filepath.Join(dir, "app_20260108.log") {
+		t.Errorf("unexpected files after cleanup: %v", matches)
+	}
+}
+
+func TestRotateWriterWeeklyMonthly(t *testing.T) {
+	tests := []struct {
+		name     string
+		rotation string
+		now      time.Time
+		file     string
+		boundary time.Time
+	}{
+		{"monthly december", "monthly", time.Date(2026, 12, 31, 23, 0, 0, 0, time.Local),
+			"app_2026-12.log", time.Date(2027, 1, 1, 0, 0, 0, 0, time.Local)},
+		{"monthly february", "monthly", time.Date(2028, 2, 29, 12, 0, 0, 0, time.Local),
+			"app_2028-02.log", time.Date(2028, 3, 1, 0, 0, 0, 0, time.Local)},
+		// 2026年有53个ISO周, 2027-01-01仍属于2026-W53
+		{"weekly 53-week year", "weekly", time.Date(2027, 1, 1, 10, 0, 0, 0, time.Local),
+			"app_2026-W53.log", time.Date(2027, 1, 4, 0, 0, 0, 0, time.Local)},
+		// 2025-12-29属于2026-W01
+		{"weekly previous year", "weekly", time.Date(2025, 12, 29, 0, 0, 0, 0, time.Local),
+			"app_2026-W01.log", time.Date(2026, 1, 5, 0, 0, 0, 0, time.Local)},
+		// 2027年只有52个ISO周
+		{"weekly 52-week year", "weekly", time.Date(2028, 1, 2, 23, 59, 0, 0, time.Local),
+			"app_2027-W52.log", time.Date(2028, 1, 3, 0, 0, 0, 0, time.Local)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			rw, err := newRotateWriter(RotateConfig{
+				TimeRotation: tt.rotation,
+				Filename:     filepath.Join(dir, "app.log"),
+			}, func() time.Time { return tt.now })
+			if err != nil {
+				t.Fatalf("newRotateWriter failed: %v", err)
+			}
+			defer rw.Close()
+
+			if got, want := rw.GetLogFilePath(), filepath.Join(dir, tt.file); got != want {
+				t.Errorf("expected %s, got %s", want, got)
+			}
+			if !rw.lastRotateTime.Equal(tt.boundary) {
+				t.Errorf("expected boundary %v, got %v", tt.boundary, rw.lastRotateTime)
+			}
+			if stamp, err := rw.parseStamp(strings.TrimSuffix(strings.TrimPrefix(tt.file, "app_"), ".log")); err != nil || !stamp.Before(tt.boundary) {
+				t.Errorf("unexpected parsed stamp %v: %v", stamp, err)
+			}
+		})
+	}
+}
+
+func TestRotateWriterBuffered(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{t: time.Date(2026, 1, 7, 10, 0, 0, 0, time.Local)}
+
+	rw, err := newRotateWriter(RotateConfig{
+		TimeRotation:  "hourly",
+		Filename:      filepath.Join(dir, "app.log"),
+		BufferSize:    4096,
+		FlushInterval: time.Hour, // 只验证显式刷新
+	}, clock.Now)
+	if err != nil {
+		t.Fatalf("newRotateWriter failed: %v", err)
+	}
+	defer rw.Close()
+
+	first := filepath.Join(dir, "app_2026-01-07_10.log")
+	rw.Write([]byte("hour-10\n"))
+	if got := readFile(t, first); got != "" {
+		t.Errorf("expected data to stay buffered, got %q", got)
+	}
+
+	// 轮转前刷新缓冲区, 数据留在旧文件
+	clock.Set(time.Date(2026, 1, 7, 11, 0, 1, 0, time.Local))
+	rw.Write([]byte("hour-11\n"))
+	if got := readFile(t, first); got != "hour-10\n" {
+		t.Errorf("unexpected content in %s: %q", first, got)
+	}
+
+	second := filepath.Join(dir, "app_2026-01-07_11.log")
+	if err := rw.Sync(); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if got := readFile(t, second); got != "hour-11\n" {
+		t.Errorf("unexpected content in %s after Sync: %q", second, got)
+	}
+}
+
+func BenchmarkRotateWriter(b *testing.B) {
+	line := []byte(`{"level":"info","msg":"benchmark message","key":"value"}` + "\n")
+	for _, bufferSize := range []int{0, 64 * 1024} {
+		b.Run(fmt.Sprintf("buffer=%d", bufferSize), func(b *testing.B) {
+			rw, err := NewRotateWriter(RotateConfig{
+				TimeRotation: "daily",
+				Filename:     filepath.Join(b.TempDir(), "app.log"),
+				BufferSize:   bufferSize,
+			})
+			if err != nil {
+				b.Fatalf("NewRotateWriter failed: %v", err)
+			}
+			defer rw.Close()
+
+			b.SetBytes(int64(len(line)))
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					rw.Write(line)
+				}
+			})
+		})
+	}
+}
+
+func TestRotateWriterRenameStyle(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "app.log")
+	clock := &fakeClock{t: time.Date(2026, 1, 7, 10, 0, 0, 0, time.Local)}
+
+	var rotated [][2]string
+	rw, err := newRotateWriter(RotateConfig{
+		TimeRotation: "daily",
+		RotateStyle:  "rename",
+		Filename:     base,
+		OnRotate: func(oldPath, newPath string) {
+			rotated = append(rotated, [2]string{oldPath, newPath})
+		},
+	}, clock.Now)
+	if err != nil {
+		t.Fatalf("newRotateWriter failed: %v", err)
+	}
+	if got := rw.GetLogFilePath(); got != base {
+		t.Errorf("expected active file %s, got %s", base, got)
+	}
+
+	rw.Write([]byte("day-7\n"))
+	clock.Set(time.Date(2026, 1, 8, 0, 0, 1, 0, time.Local))
+	rw.Write([]byte("day-8\n"))
+
+	// 手动轮转按序号滚动
+	if err := rw.Rotate(); err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+	rw.Write([]byte("after-rotate\n"))
+	if err := rw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	backup := filepath.Join(dir, "app_2026-01-07.log")
+	if got := readFile(t, backup); got != "day-7\n" {
+		t.Errorf("unexpected content in %s: %q", backup, got)
+	}
+	if got := readFile(t, filepath.Join(dir, "app.1.log")); got != "day-8\n" {
+		t.Errorf("unexpected content in app.1.log: %q", got)
+	}
+	if got := readFile(t, base); got != "after-rotate\n" {
+		t.Errorf("unexpected content in %s: %q", base, got)
+	}
+	if len(rotated) == 0 || rotated[0] != [2]string{backup, base} {
+		t.Errorf("unexpected rotate callbacks: %v", rotated)
+	}
+}
+
+func TestRotateWriterRenameStyleStale(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "app.log")
+
+	// 上次运行留下的前一天的文件
+	if err := os.WriteFile(base, []byte("yesterday\n"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	yesterday := time.Date(2026, 1, 6, 12, 0, 0, 0, time.Local)
+	os.Chtimes(base, yesterday, yesterday)
+
+	clock := &fakeClock{t: time.Date(2026, 1, 7, 10, 0, 0, 0, time.Local)}
+	rw, err := newRotateWriter(RotateConfig{
+		TimeRotation: "daily",
+		RotateStyle:  "rename",
+		Filename:     base,
+	}, clock.Now)
+	if err != nil {
+		t.Fatalf("newRotateWriter failed: %v", err)
+	}
+	rw.Write([]byte("today\n"))
+	rw.Close()
+
+	if got := readFile(t, filepath.Join(dir, "app_2026-01-06.log")); got != "yesterday\n" {
+		t.Errorf("expected stale file to be renamed, got %q", got)
+	}
+	if got := readFile(t, base); got != "today\n" {
+		t.Errorf("unexpected content in %s: %q", base, got)
+	}
+}
+
+func TestRotateWriterStats(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{t: time.Date(2026, 1, 7, 10, 30, 0, 0, time.Local)}
+
+	rw, err := newRotateWriter(RotateConfig{
+		TimeRotation: "hourly",
+		Filename:     filepath.Join(dir, "app.log"),
+	}, clock.Now)
+	if err != nil {
+		t.Fatalf("newRotateWriter failed: %v", err)
+	}
+	defer rw.Close()
+
+	rw.Write([]byte("0123456789\n"))
+	rw.Write([]byte("abc\n"))
+	if got := rw.CurrentSize(); got != 15 {
+		t.Errorf("expected CurrentSize 15, got %d", got)
+	}
+	if got, want := rw.NextRotation(), time.Date(2026, 1, 7, 11, 0, 0, 0, time.Local); !got.Equal(want) {
+		t.Errorf("expected NextRotation %v, got %v", want, got)
+	}
+
+	// 轮转后大小从新文件开始计算
+	clock.Set(time.Date(2026, 1, 7, 11, 0, 1, 0, time.Local))
+	rw.Write([]byte("new\n"))
+	if got := rw.CurrentSize(); got != 4 {
+		t.Errorf("expected CurrentSize 4 after rotation, got %d", got)
+	}
+	if got, want := rw.NextRotation(), time.Date(2026, 1, 7, 12, 0, 0, 0, time.Local); !got.Equal(want) {
+		t.Errorf("expected NextRotation %v, got %v", want, got)
+	}
+}
+
+func TestRotateWriterReopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	rw, err := NewRotateWriter(RotateConfig{Filename: path})
+	if err != nil {
+		t.Fatalf("NewRotateWriter failed: %v", err)
+	}
+	defer rw.Close()
+
+	rw.Write([]byte("before\n"))
+
+	// 模拟外部logrotate重命名文件
+	moved := path + ".1"
+	if err := os.Rename(path, moved); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	rw.Write([]byte("still old inode\n"))
+
+	if err := rw.Reopen(); err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	rw.Write([]byte("after\n"))
+	rw.Sync()
+
+	if got := readFile(t, moved); got != "before\nstill old inode\n" {
+		t.Errorf("unexpected content in renamed file: %q", got)
+	}
+	if got := readFile(t, path); got != "after\n" {
+		t.Errorf("unexpected content in reopened file: %q", got)
+	}
+	if got := rw.CurrentSize(); got != int64(len("after\n")) {
+		t.Errorf("expected CurrentSize %d, got %d", len("after\n"), got)
+	}
+}
+
+func TestRotateWriterTimeAndSize(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{t: time.Date(2026, 1, 7, 10, 0, 30, 0, time.Local)}
+
+	rw, err := newRotateWriter(RotateConfig{
+		TimeRotation: "minutely",
+		MaxSize:      1,
+		Filename:     filepath.Join(dir, "app.log"),
+	}, clock.Now)
+	if err != nil {
+		t.Fatalf("newRotateWriter failed: %v", err)
+	}
+	defer rw.Close()
+
+	const mb = 1024 * 1024
+	rw.Write(bytes.Repeat([]byte{'a'}, mb-10))
+
+	// 跨分钟边界的大行写入新分钟的文件, 而不是按大小滚动旧文件
+	clock.Set(time.Date(2026, 1, 7, 10, 1, 0, 500, time.Local))
+	rw.Write(bytes.Repeat([]byte{'b'}, mb+100))
+	// 同一分钟内的下一次写入按大小滚动
+	rw.Write([]byte("c\n"))
+	rw.Sync()
+
+	check := func(name string, c byte, size int) {
+		t.Helper()
+		data := readFile(t, filepath.Join(dir, name))
+		if len(data) != size || (size > 0 && data[0] != c) {
+			t.Errorf("unexpected content in %s: len=%d", name, len(data))
+		}
+	}
+	check("app_2026-01-07_10_00.log", 'a', mb-10)
+	check("app_2026-01-07_10_01.1.log", 'b', mb+100)
+	check("app_2026-01-07_10_01.log", 'c', 2)
+
+	backups, err := rw.listBackups()
+	if err != nil {
+		t.Fatalf("listBackups failed: %v", err)
+	}
+	var names []string
+	for _, b := range backups {
+		names = append(names, filepath.Base(b.base))
+	}
+	want := []string{"app_2026-01-07_10_00.log", "app_2026-01-07_10_01.1.log", "app_2026-01-07_10_01.log"}
+	if fmt.Sprint(names) != fmt.Sprint(want) {
+		t.Errorf("expected backups %v, got %v", want, names)
+	}
+}
+
+func TestRotateWriterFallback(t *testing.T) {
+	// /dev/full的写入总是返回ENOSPC, 用于模拟磁盘已满
+	full, err := os.OpenFile("/dev/full", os.O_WRONLY, 0)
+	if err != nil {
+		t.Skipf("/dev/full not available: %v", err)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	var failures []int
+	rw, err := NewRotateWriter(RotateConfig{
+		Filename:          path,
+		FallbackThreshold: 2,
+		OnWriteError: func(err error, n int) {
+			if !errors.Is(err, syscall.ENOSPC) {
+				t.Errorf("expected ENOSPC, got %v", err)
+			}
+			failures = append(failures, n)
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewRotateWriter failed: %v", err)
+	}
+	defer rw.Close()
+	var stderr bytes.Buffer
+	rw.fallback = &stderr
+
+	rw.mu.Lock()
+	good := rw.file
+	rw.setFile(full)
+	rw.mu.Unlock()
+
+	// 未达到阈值时返回错误, 达到后写入备用输出
+	if _, err := rw.Write([]byte("first\n")); err == nil {
+		t.Error("expected error before reaching threshold")
+	}
+	if n, err := rw.Write([]byte("second\n")); err != nil || n != len("second\n") {
+		t.Errorf("expected fallback write to succeed, got %d, %v", n, err)
+	}
+	rw.Write([]byte("third\n"))
+	if stderr.String() != "second\nthird\n" {
+		t.Errorf("unexpected fallback output: %q", stderr.String())
+	}
+	if len(failures) != 3 || failures[2] != 3 {
+		t.Errorf("unexpected failure counts: %v", failures)
+	}
+
+	// 磁盘恢复后写回文件并重置计数
+	rw.mu.Lock()
+	rw.setFile(good)
+	rw.mu.Unlock()
+	full.Close()
+	rw.Write([]byte("recovered\n"))
+	rw.Sync()
+	if got := readFile(t, path); got != "recovered\n" {
+		t.Errorf("unexpected file content: %q", got)
+	}
+	if stderr.String() != "second\nthird\n" {
+		t.Errorf("fallback should not be used after recovery: %q", stderr.String())
+	}
+	if rw.failures != 0 {
+		t.Errorf("expected failures reset, got %d", rw.failures)
+	}
+}
+
+func TestRotateWriterFileMode(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "logs")
+	path := filepath.Join(dir, "app.log")
+
+	rw, err := NewRotateWriter(RotateConfig{Filename: path, FileMode: 0600, DirMode: 0700})
+	if err != nil {
+		t.Fatalf("NewRotateWriter failed: %v", err)
+	}
+	rw.Write([]byte("secret\n"))
+	rw.Close()
+
+	checkMode := func(path string, want os.FileMode) {
+		t.Helper()
+		stat, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("Stat failed: %v", err)
+		}
+		if got := stat.Mode().Perm(); got != want {
+			t.Errorf("%s: expected mode %v, got %v", path, want, got)
+		}
+	}
+	checkMode(dir, 0700)
+	checkMode(path, 0600)
+
+	// 压缩文件沿用原文件的权限
+	if err := compressFile(path, 0); err != nil {
+		t.Fatalf("compressFile failed: %v", err)
+	}
+	checkMode(path+compressSuffix, 0600)
+}
+
+func TestRotateWriterMaxTotalSize(t *testing.T) {
+	const mb = 1024 * 1024
+	chunk := bytes.Repeat([]byte("x"), mb/4)
+
+	// dirSize 目录中所有文件的总大小和文件数
+	dirSize := func(dir string) (int64, int) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatalf("ReadDir failed: %v", err)
+		}
+		var total int64
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if err != nil {
+				t.Fatalf("Info failed: %v", err)
+			}
+			total += info.Size()
+		}
+		return total, len(entries)
+	}
+
+	t.Run("indexed", func(t *testing.T) {
+		dir := t.TempDir()
+		rw, err := NewRotateWriter(RotateConfig{Filename: filepath.Join(dir, "app.log"), MaxSize: 1, MaxTotalSize: 3})
+		if err != nil {
+			t.Fatalf("NewRotateWriter failed: %v", err)
+		}
+		defer rw.Close()
+
+		for i := 0; i < 32; i++ {
+			rw.Write(chunk)
+		}
+		// 清理在轮转后执行, 轮转后检查
+		if err := rw.Rotate(); err != nil {
+			t.Fatalf("Rotate failed: %v", err)
+		}
+		if total, files := dirSize(dir); total > 3*mb || files < 3 {
+			t.Errorf("expected total size <= 3MB with at least 3 files, got %d bytes in %d files", total, files)
+		}
+		// 最旧的备份先被删除
+		if fileExists(filepath.Join(dir, "app.7.log")) || !fileExists(filepath.Join(dir, "app.1.log")) {
+			t.Error("expected oldest backups removed first")
+		}
+	})
+
+	t.Run("time and size", func(t *testing.T) {
+		dir := t.TempDir()
+		clock := &fakeClock{t: time.Date(2026, 1, 7, 10, 0, 0, 0, time.Local)}
+		rw, err := newRotateWriter(RotateConfig{
+			Filename:     filepath.Join(dir, "app.log"),
+			TimeRotation: "minutely",
+			MaxSize:      1,
+			MaxTotalSize: 2,
+		}, clock.Now)
+		if err != nil {
+			t.Fatalf("NewRotateWriter failed: %v", err)
+		}
+		defer rw.Close()
+
+		for minute := 0; minute < 3; minute++ {
+			clock.Set(time.Date(2026, 1, 7, 10, minute, 30, 0, time.Local))
+			for i := 0; i < 6; i++ {
+				rw.Write(chunk)
+			}
+		}
+		clock.Set(time.Date(2026, 1, 7, 10, 3, 30, 0, time.Local))
+		rw.Write(nil)
+		if total, _ := dirSize(dir); total > 2*mb {
+			t.Errorf("expected total size <= 2MB, got %d bytes", total)
+		}
+		if !fileExists(rw.GetLogFilePath()) {
+			t.Error("current file should not be removed")
+		}
+	})
+}
+
+func TestRotateWriterFallbackOnOpenError(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "logs")
+	path := filepath.Join(dir, "app.log")
+	var failures []int
+	rw, err := NewRotateWriter(RotateConfig{
+		Filename:          path,
+		FallbackThreshold: 1,
+		OnWriteError:      func(err error, n int) { failures = append(failures, n) },
+	})
+	if err != nil {
+		t.Fatalf("NewRotateWriter failed: %v", err)
+	}
+	defer rw.Close()
+	var stderr bytes.Buffer
+	rw.fallback = &stderr
+
+	// 关闭当前文件并让目录无法创建, 下次写入时重新打开失败
+	rw.mu.Lock()
+	rw.closeFile()
+	rw.file = nil
+	rw.mu.Unlock()
+	os.RemoveAll(dir)
+	if err := os.WriteFile(dir, nil, 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	if n, err := rw.Write([]byte("lost file\n")); err != nil || n != len("lost file\n") {
+		t.Errorf("expected fallback write to succeed, got %d, %v", n, err)
+	}
+	if stderr.String() != "lost file\n" {
+		t.Errorf("unexpected fallback output: %q", stderr.String())
+	}
+	if len(failures) != 1 || failures[0] != 1 {
+		t.Errorf("unexpected failure counts: %v", failures)
+	}
+
+	// 部分写入文件后失败时, 只将其余数据写入备用输出
+	stderr.Reset()
+	rw.mu.Lock()
+	n, err := rw.writeFailed([]byte("partial\n"), 4, syscall.ENOSPC)
+	rw.mu.Unlock()
+	if n != len("partial\n") || err != nil || stderr.String() != "ial\n" {
+		t.Errorf("unexpected partial fallback: %d, %v, %q", n, err, stderr.String())
+	}
+}
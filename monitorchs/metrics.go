@@ -0,0 +1,114 @@
+package monitorchs
+
+import (
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"strconv"
+)
+
+// promMetrics 注册到Prometheus的通道长度和容量指标
+type promMetrics struct {
+	registerer prometheus.Registerer
+	length     *prometheus.GaugeVec
+	capacity   *prometheus.GaugeVec
+	registered []prometheus.Collector // 由本监控注册成功的指标, Stop时注销
+}
+
+// WithPrometheus 将通道长度和容量注册为Prometheus指标, 标签为name(通道组名)和index(组内下标)
+//
+// 指标名为 <namespace>_channel_length 和 <namespace>_channel_capacity, 每次采样时更新, Stop时注销;
+// registerer上已存在同名指标时复用, Stop时只删除本监控写入的标签值, 共用时各监控的组名应不同
+func WithPrometheus[T any](registerer prometheus.Registerer, namespace string) Options[T] {
+	return func(m *MonitorChs[T]) {
+		labels := []string{"name", "index"}
+		m.prom = &promMetrics{
+			registerer: registerer,
+			length: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "channel_length",
+				Help:      "Current number of elements queued in the monitored channel.",
+			}, labels),
+			capacity: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "channel_capacity",
+				Help:      "Buffer capacity of the monitored channel.",
+			}, labels),
+		}
+	}
+}
+
+// register 注册指标, 已存在同名指标时复用已注册的指标, 复用的指标不会被unregister注销
+func (p *promMetrics) register() error {
+	var errs []error
+	for _, vec := range []**prometheus.GaugeVec{&p.length, &p.capacity} {
+		err := p.registerer.Register(*vec)
+		if err == nil {
+			p.registered = append(p.registered, *vec)
+			continue
+		}
+		if are, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			if gv, ok := are.ExistingCollector.(*prometheus.GaugeVec); ok {
+				*vec = gv
+				continue
+			}
+		}
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// unregister 注销由本监控注册的指标
+func (p *promMetrics) unregister() {
+	for _, c := range p.registered {
+		p.registerer.Unregister(c)
+	}
+	p.registered = nil
+}
+
+// deleteLabels 删除name组第index个通道的指标
+func (p *promMetrics) deleteLabels(name, index string) {
+	p.length.DeleteLabelValues(name, index)
+	p.capacity.DeleteLabelValues(name, index)
+}
+
+// deleteGroup 删除name组的所有指标
+func (p *promMetrics) deleteGroup(name string) {
+	p.length.DeletePartialMatch(prometheus.Labels{"name": name})
+	p.capacity.DeletePartialMatch(prometheus.Labels{"name": name})
+}
+
+// registerMetrics 注册Prometheus指标, 失败时输出错误日志
+func (m *MonitorChs[T]) registerMetrics() {
+	if m.prom == nil {
+		return
+	}
+	if err := m.prom.register(); err != nil && m.hLog != nil {
+		m.hLog.Error("ch monitor prometheus register failed", zap.Error(err))
+	}
+	m.buildGauges()
+}
+
+// unregisterMetrics 删除本监控写入的指标并注销由本监控注册的指标, 调用方需持有m.mu
+func (m *MonitorChs[T]) unregisterMetrics() {
+	if m.prom == nil {
+		return
+	}
+	for i := range m.fieldChs {
+		m.prom.deleteLabels(m.fieldNames[i], strconv.Itoa(m.fieldIdx[i]))
+	}
+	m.prom.unregister()
+}
+
+// buildGauges 根据当前通道生成长度指标并设置容量, 调用方需持有m.mu或在构造期间调用
+func (m *MonitorChs[T]) buildGauges() {
+	if m.prom == nil {
+		return
+	}
+	m.lenGauges = make([]prometheus.Gauge, len(m.fieldChs))
+	for i, ch := range m.fieldChs {
+		index := strconv.Itoa(m.fieldIdx[i])
+		m.lenGauges[i] = m.prom.length.WithLabelValues(m.fieldNames[i], index)
+		m.prom.capacity.WithLabelValues(m.fieldNames[i], index).Set(float64(ch.Cap()))
+	}
+}
@@ -0,0 +1,116 @@
+package monitorchs
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"testing"
+)
+
+// gaugeValues 从registry中读取指定指标, 返回 "name/index" -> 值
+func gaugeValues(t *testing.T, reg *prometheus.Registry, metric string) map[string]float64 {
+	t.Helper()
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("gather failed: %v", err)
+	}
+	values := make(map[string]float64)
+	for _, family := range families {
+		if family.GetName() != metric {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			var name, index string
+			for _, label := range m.GetLabel() {
+				switch label.GetName() {
+				case "name":
+					name = label.GetValue()
+				case "index":
+					index = label.GetValue()
+				}
+			}
+			values[name+"/"+index] = m.GetGauge().GetValue()
+		}
+	}
+	return values
+}
+
+func TestMonitorChsPrometheus(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	chs := []chan int{make(chan int, 10), make(chan int, 5)}
+	chs[0] <- 1
+	chs[0] <- 2
+	chs[1] <- 3
+
+	m := NewMonitorChs(
+		WithChs("workers", chs),
+		WithLog[int](nopLogger{}),
+		WithPrometheus[int](reg, "app"),
+	)
+	m.tick()
+
+	lengths := gaugeValues(t, reg, "app_channel_length")
+	if lengths["workers/0"] != 2 || lengths["workers/1"] != 1 {
+		t.Errorf("unexpected lengths: %v", lengths)
+	}
+	capacities := gaugeValues(t, reg, "app_channel_capacity")
+	if capacities["workers/0"] != 10 || capacities["workers/1"] != 5 {
+		t.Errorf("unexpected capacities: %v", capacities)
+	}
+
+	<-chs[0]
+	m.tick()
+	if lengths = gaugeValues(t, reg, "app_channel_length"); lengths["workers/0"] != 1 {
+		t.Errorf("expected updated length 1, got %v", lengths)
+	}
+
+	// Stop后注销, 同一registry可以再次注册
+	m.Stop()
+	if families, _ := reg.Gather(); len(families) != 0 {
+		t.Errorf("expected metrics to be unregistered, got %d families", len(families))
+	}
+	m2 := NewMonitorChs(WithChs("workers", chs), WithLog[int](nopLogger{}), WithPrometheus[int](reg, "app"))
+	m2.tick()
+	if lengths = gaugeValues(t, reg, "app_channel_length"); lengths["workers/0"] != 1 {
+		t.Errorf("expected re-registered length 1, got %v", lengths)
+	}
+	m2.Stop()
+}
+
+func TestMonitorChsPrometheusShared(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m1 := NewMonitorChs(WithCh("a", make(chan int, 1)), WithLog[int](nopLogger{}), WithPrometheus[int](reg, "app"))
+	defer m1.Stop()
+	m2 := NewMonitorChs(WithCh("b", make(chan int, 2)), WithLog[int](nopLogger{}), WithPrometheus[int](reg, "app"))
+	m1.tick()
+	m2.tick()
+	if capacities := gaugeValues(t, reg, "app_channel_capacity"); capacities["a/0"] != 1 || capacities["b/0"] != 2 {
+		t.Fatalf("expected both monitors to share the gauges, got %v", capacities)
+	}
+
+	// 复用的指标不注销, 只删除m2写入的标签值
+	m2.Stop()
+	for _, metric := range []string{"app_channel_length", "app_channel_capacity"} {
+		values := gaugeValues(t, reg, metric)
+		if _, ok := values["b/0"]; ok || len(values) != 1 {
+			t.Errorf("expected only m1 series in %s, got %v", metric, values)
+		}
+	}
+}
+
+func TestMonitorChsPrometheusAddRemove(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := NewMonitorChs(WithCh("a", make(chan int, 1)), WithLog[int](nopLogger{}), WithPrometheus[int](reg, "app"))
+	defer m.Stop()
+
+	m.AddChannels("b", make(chan int, 3))
+	m.tick()
+	if capacities := gaugeValues(t, reg, "app_channel_capacity"); capacities["b/0"] != 3 {
+		t.Errorf("expected added channel gauge, got %v", capacities)
+	}
+
+	m.RemoveGroup("a")
+	m.tick()
+	lengths := gaugeValues(t, reg, "app_channel_length")
+	if _, ok := lengths["a/0"]; ok || len(lengths) != 1 {
+		t.Errorf("expected removed group gauges to be deleted, got %v", lengths)
+	}
+}
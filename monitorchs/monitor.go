@@ -9,9 +9,13 @@
 package monitorchs
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/calmu/hgotool/hlog"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
+	"sort"
 	"sync"
 	"time"
 )
@@ -20,13 +24,80 @@ const (
 	MonitorDuration time.Duration = time.Minute
 )
 
+// 通道告警状态
+const (
+	StateAlert = "alert"
+	StateOK    = "ok"
+)
+
 type Options[T any] func(m *MonitorChs[T])
 
+// thresholdHit 单次采样中超过阈值的通道
+type thresholdHit struct {
+	name     string
+	index    int
+	length   int
+	capacity int
+}
+
+// stateChange 单次采样中告警状态发生变化的通道
+type stateChange struct {
+	name  string
+	index int
+	state string
+}
+
+// sampleEvents 单次采样中需要在释放m.mu后触发的回调
+type sampleEvents struct {
+	hits   []thresholdHit
+	states []stateChange
+}
+
+// ChannelStat 单个通道的采样结果
+type ChannelStat struct {
+	Len int
+	Cap int
+}
+
 type MonitorChs[T any] struct {
-	chs             map[string][]chan T
+	chs             map[string][]chanProbe
 	quitCh          chan struct{}
+	doneCh          chan struct{}      // 监控协程退出时关闭
+	stopOnce        sync.Once          // 保证只通知一次监控协程退出
+	cancel          context.CancelFunc // 取消RunContext派生的context
 	monitorDuration time.Duration
 	hLog            hlog.HLoggerBase
+
+	alertRatio    float64                                  // 告警阈值, len/cap 超过该值视为告警
+	onStateChange func(name string, idx int, state string) // 告警状态变化回调, 在锁外调用
+	alertStates   map[string][]bool                        // 各通道当前是否处于告警状态
+	onlyOnChange  bool                                     // 仅输出长度发生变化的通道
+	sinks         []Sink                                   // 采样结果的额外输出
+	invalid       []error                                  // 配置阶段发现的nil通道、无缓冲通道和无效级别
+	level         string                                   // 常规输出的日志级别, 为空时为warn
+	report        func(msg string, fields ...zap.Field)    // 按level解析出的常规输出方法
+
+	threshold   float64                                            // 阈值, len/cap 超过该值时触发onThreshold
+	onThreshold func(name string, index int, length, capacity int) // 超过阈值回调, 在锁外调用
+
+	prom      *promMetrics       // Prometheus指标, 未开启时为nil
+	lenGauges []prometheus.Gauge // 与fieldChs一一对应的长度指标
+
+	mu sync.Mutex // 保护采样过程和chs, Run与SampleNow、AddChannels等可能并发执行
+
+	// 预先计算的输出字段, 避免每次采样重复分配
+	fieldNames []string
+	fieldKeys  []string
+	fieldIdx   []int // 通道在所属组内的下标
+	fieldChs   []chanProbe
+	fields     []zap.Field
+	lastLens   []int       // 上次采样的长度, 用于onlyOnChange和delta
+	changed    []zap.Field // 本次长度发生变化的字段, 用于onlyOnChange
+
+	sampleInterval time.Duration // 两次输出之间的快速采样间隔, 为0时不记录max和delta
+	maxLens        []int         // 自上次输出以来快速采样到的最大长度
+	maxKeys        []string
+	deltaKeys      []string
 }
 
 // NewMonitorChs
@@ -42,8 +113,29 @@ type MonitorChs[T any] struct {
 //
 // --------------------------------------------
 func NewMonitorChs[T any](options ...Options[T]) *MonitorChs[T] {
+	m := newMonitorChs(options...)
+	if err := errors.Join(m.invalid...); err != nil && m.hLog != nil {
+		m.hLog.Warn("ch monitor invalid channels", zap.Error(err))
+	}
+	m.invalid = nil
+	m.init()
+	return m
+}
+
+// NewMonitorChsE 与NewMonitorChs相同, 但存在nil通道或无缓冲通道时返回错误而不是仅输出警告
+func NewMonitorChsE[T any](options ...Options[T]) (*MonitorChs[T], error) {
+	m := newMonitorChs(options...)
+	if err := errors.Join(m.invalid...); err != nil {
+		return nil, err
+	}
+	m.init()
+	return m, nil
+}
+
+// newMonitorChs 应用配置并补全默认值
+func newMonitorChs[T any](options ...Options[T]) *MonitorChs[T] {
 	m := &MonitorChs[T]{
-		chs: make(map[string][]chan T), // 初始化chs map
+		chs: make(map[string][]chanProbe), // 初始化chs map
 	}
 
 	for _, option := range options {
@@ -57,28 +149,166 @@ func NewMonitorChs[T any](options ...Options[T]) *MonitorChs[T] {
 	if m.monitorDuration == 0 {
 		m.monitorDuration = MonitorDuration
 	}
+	m.resolveReport()
 	return m
 }
 
+// resolveReport 按level选择常规输出方法, hLog未实现对应级别的方法时回退到Warn
+func (m *MonitorChs[T]) resolveReport() {
+	if m.hLog == nil {
+		return
+	}
+	m.report = m.hLog.Warn
+	switch m.level {
+	case "", "warn":
+	case "info":
+		if l, ok := m.hLog.(interface{ Info(string, ...zap.Field) }); ok {
+			m.report = l.Info
+		}
+	case "debug":
+		if l, ok := m.hLog.(interface{ Debug(string, ...zap.Field) }); ok {
+			m.report = l.Debug
+		}
+	default:
+		m.invalid = append(m.invalid, fmt.Errorf("invalid level %q, using warn", m.level))
+	}
+}
+
+// init 生成输出字段并注册指标
+func (m *MonitorChs[T]) init() {
+	m.buildFields()
+	m.registerMetrics()
+}
+
+// validChs 过滤nil通道, 返回过滤后的通道以及nil通道和无缓冲通道对应的错误(无缓冲通道仍会保留)
+func validChs[T any](name string, chs []chan T) ([]chanProbe, []error) {
+	var errs []error
+	valid := make([]chanProbe, 0, len(chs))
+	for i, ch := range chs {
+		switch {
+		case ch == nil:
+			errs = append(errs, fmt.Errorf("channel %s[%d] is nil and will be skipped", name, i))
+			continue
+		case cap(ch) == 0:
+			errs = append(errs, fmt.Errorf("channel %s[%d] is unbuffered, its length is always 0", name, i))
+		}
+		valid = append(valid, typedProbe[T](ch))
+	}
+	return valid, errs
+}
+
+// buildFields 根据已注册的通道预先生成字段键名和字段切片, 调用方需持有m.mu或在构造期间调用
+//
+// 重建时保留仍在监控中的通道的上次采样长度
+func (m *MonitorChs[T]) buildFields() {
+	last := make(map[string]int, len(m.fieldKeys))
+	for i, key := range m.fieldKeys {
+		last[key] = m.lastLens[i]
+	}
+
+	names := make([]string, 0, len(m.chs))
+	ll := 0
+	for name, chs := range m.chs {
+		names = append(names, name)
+		ll += len(chs)
+	}
+	sort.Strings(names)
+
+	m.fieldNames = make([]string, 0, ll)
+	m.fieldKeys = make([]string, 0, ll)
+	m.fieldIdx = make([]int, 0, ll)
+	m.fieldChs = make([]chanProbe, 0, ll)
+	for _, name := range names {
+		for i, ch := range m.chs[name] {
+			m.fieldNames = append(m.fieldNames, name)
+			m.fieldKeys = append(m.fieldKeys, fmt.Sprintf("%sch%v len", name, i))
+			m.fieldIdx = append(m.fieldIdx, i)
+			m.fieldChs = append(m.fieldChs, ch)
+		}
+	}
+	m.maxLens = nil
+	m.maxKeys = nil
+	m.deltaKeys = nil
+	fieldsLen := ll
+	if m.sampleInterval > 0 {
+		m.maxLens = make([]int, ll)
+		m.maxKeys = make([]string, ll)
+		m.deltaKeys = make([]string, ll)
+		for i := range m.fieldKeys {
+			m.maxKeys[i] = fmt.Sprintf("%sch%v max", m.fieldNames[i], m.fieldIdx[i])
+			m.deltaKeys[i] = fmt.Sprintf("%sch%v delta", m.fieldNames[i], m.fieldIdx[i])
+		}
+		// 长度字段在前, 每个通道的max和delta字段依次追加在后
+		fieldsLen = ll * 3
+	}
+	m.fields = make([]zap.Field, fieldsLen)
+	m.lastLens = make([]int, ll)
+	for i, key := range m.fieldKeys {
+		m.lastLens[i] = last[key]
+	}
+	m.changed = make([]zap.Field, 0, fieldsLen)
+}
+
+// AddChannels 在运行期间向name组追加通道, 组不存在时新建
+//
+// nil通道会被跳过, nil通道和无缓冲通道会输出警告日志
+func (m *MonitorChs[T]) AddChannels(name string, chs ...chan T) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	valid, errs := validChs(name, chs)
+	m.addProbes(name, valid, errs)
+}
+
+// addProbes 追加通道并重建字段, 无效通道输出警告日志, 调用方需持有m.mu
+func (m *MonitorChs[T]) addProbes(name string, valid []chanProbe, errs []error) {
+	if len(errs) > 0 && m.hLog != nil {
+		m.hLog.Warn("ch monitor invalid channels", zap.Error(errors.Join(errs...)))
+	}
+	if len(valid) == 0 {
+		return
+	}
+	m.chs[name] = append(m.chs[name], valid...)
+	m.buildFields()
+	m.buildGauges()
+}
+
+// RemoveGroup 在运行期间移除name组的所有通道, 组不存在时不做任何处理
+func (m *MonitorChs[T]) RemoveGroup(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.chs[name]; !ok {
+		return
+	}
+	delete(m.chs, name)
+	delete(m.alertStates, name)
+	m.buildFields()
+	if m.prom != nil {
+		m.prom.deleteGroup(name)
+	}
+	m.buildGauges()
+}
+
 func WithChs[T any](name string, chs []chan T) Options[T] {
 	return func(m *MonitorChs[T]) {
 		if m.chs == nil {
-			m.chs = make(map[string][]chan T)
+			m.chs = make(map[string][]chanProbe)
 		}
-		m.chs[name] = chs
+		valid, errs := validChs(name, chs)
+		m.chs[name] = valid
+		m.invalid = append(m.invalid, errs...)
 	}
 }
 
 func WithCh[T any](name string, chs ...chan T) Options[T] {
 	return func(m *MonitorChs[T]) {
 		if m.chs == nil {
-			m.chs = make(map[string][]chan T)
-		}
-		if m.chs[name] == nil {
-			m.chs[name] = chs
-		} else {
-			m.chs[name] = append(m.chs[name], chs...)
+			m.chs = make(map[string][]chanProbe)
 		}
+		valid, errs := validChs(name, chs)
+		m.chs[name] = append(m.chs[name], valid...)
+		m.invalid = append(m.invalid, errs...)
 	}
 }
 
@@ -100,49 +330,326 @@ func WithHLog[T any]() Options[T] {
 	}
 }
 
+// WithAlertRatio 设置告警阈值, 当 len(ch)/cap(ch) 超过 ratio 时通道进入告警状态
+func WithAlertRatio[T any](ratio float64) Options[T] {
+	return func(m *MonitorChs[T]) {
+		m.alertRatio = ratio
+	}
+}
+
+// WithOnStateChange 设置告警状态变化回调, 仅在通道由ok变为alert或由alert变为ok时触发
+//
+// 回调在释放内部锁后调用, 其中可以调用Snapshot、SampleNow等方法
+func WithOnStateChange[T any](fn func(name string, idx int, state string)) Options[T] {
+	return func(m *MonitorChs[T]) {
+		m.onStateChange = fn
+	}
+}
+
+// WithOnlyOnChange 仅输出长度与上次采样不同的通道, 没有通道变化时不输出日志
+//
+// 通道初始长度视为0, 因此一直为空的通道不会被输出
+func WithOnlyOnChange[T any](onlyOnChange bool) Options[T] {
+	return func(m *MonitorChs[T]) {
+		m.onlyOnChange = onlyOnChange
+	}
+}
+
+// WithThreshold 设置阈值回调, 每次采样时 len(ch)/cap(ch) 超过 ratio 的通道都会触发一次fn, 定时日志照常输出
+//
+// fn在采样锁释放后调用, 可以安全地检查通道或调用SampleNow
+func WithThreshold[T any](ratio float64, fn func(name string, index int, length, capacity int)) Options[T] {
+	return func(m *MonitorChs[T]) {
+		m.threshold = ratio
+		m.onThreshold = fn
+	}
+}
+
+// WithSampleInterval 在两次输出之间按interval快速采样, 输出时额外记录各通道期间的最大长度(namechN max)
+// 和与上次输出相比的长度变化(namechN delta), 用于发现在两次输出之间写满又被消费的突发流量
+//
+// interval应小于WithDuration设置的输出间隔, 计数在每次输出后重置
+func WithSampleInterval[T any](interval time.Duration) Options[T] {
+	return func(m *MonitorChs[T]) {
+		m.sampleInterval = interval
+	}
+}
+
+// WithLevel 设置常规输出的日志级别(debug/info/warn, 默认warn), 有通道超过WithAlertRatio或WithThreshold
+// 的阈值时本次输出仍使用Warn
+//
+// hLog需实现对应的Info/Debug方法(如hlog.HLogger), 否则回退到Warn
+func WithLevel[T any](level string) Options[T] {
+	return func(m *MonitorChs[T]) {
+		m.level = level
+	}
+}
+
+// WithSink 添加采样结果的额外输出(如CSVSink), Stop时会刷新并关闭
+func WithSink[T any](sink Sink) Options[T] {
+	return func(m *MonitorChs[T]) {
+		m.sinks = append(m.sinks, sink)
+	}
+}
+
 func (m *MonitorChs[T]) Run(wg *sync.WaitGroup) {
+	m.RunContext(context.Background(), wg)
+}
+
+// RunContext 启动监控协程, ctx取消或调用Stop时退出, 退出时调用wg.Done
+//
+// Stop会取消由ctx派生的内部context, 因此两种方式可以混用
+func (m *MonitorChs[T]) RunContext(ctx context.Context, wg *sync.WaitGroup) {
+	ctx, m.cancel = context.WithCancel(ctx)
 	m.quitCh = make(chan struct{}, 1)
+	m.doneCh = make(chan struct{})
 	ticker := time.NewTicker(m.monitorDuration)
 	go func() {
 		defer wg.Done()
+		defer close(m.doneCh)
+		defer ticker.Stop()
+
+		// 未开启快速采样时fastC为nil, 对应分支永远不会被选中
+		var fastC <-chan time.Time
+		if m.sampleInterval > 0 {
+			fastTicker := time.NewTicker(m.sampleInterval)
+			defer fastTicker.Stop()
+			fastC = fastTicker.C
+		}
 		for {
 			select {
+			case <-fastC:
+				m.fastSample()
 			case <-ticker.C:
-				if m.chs == nil {
-					continue
-				}
-				ll := 0
-				for _, chs := range m.chs {
-					ll += len(chs)
-				}
-				if ll == 0 {
-					continue
-				}
-				fields := make([]zap.Field, 0, ll)
-				for name, chs := range m.chs {
-					for i, ch := range chs {
-						fields = append(fields, zap.Any(fmt.Sprintf("%sch%v len", name, i), len(ch)))
-					}
-				}
-
-				// 确保hLog不为nil
-				if m.hLog != nil {
-					m.hLog.Warn("ch len monitor", fields...)
-				}
+				m.tick()
+			case <-ctx.Done():
+				return
 			case <-m.quitCh:
-				ticker.Stop()
 				return
 			}
 		}
 	}()
 }
 
+// SampleNow 同步执行一次采样(与定时采样逻辑相同, 包括输出日志和告警状态检查), 并返回各通道的采样结果
+//
+// 无需调用Run, 便于在测试或健康检查中确定性地获取结果
+func (m *MonitorChs[T]) SampleNow() map[string][]ChannelStat {
+	m.mu.Lock()
+	events := m.sample()
+	stats := m.stats()
+	m.mu.Unlock()
+
+	m.fire(events)
+	return stats
+}
+
+// Snapshot 返回各通道当前长度, 不输出日志也不触发回调, 适合健康检查等需要即时读数的场景
+func (m *MonitorChs[T]) Snapshot() map[string][]int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := make(map[string][]int, len(m.chs))
+	for name, chs := range m.chs {
+		lens := make([]int, len(chs))
+		for i, ch := range chs {
+			lens[i] = ch.Len()
+		}
+		snapshot[name] = lens
+	}
+	return snapshot
+}
+
+// stats 根据最近一次采样的字段生成采样结果, 调用方需持有m.mu
+func (m *MonitorChs[T]) stats() map[string][]ChannelStat {
+	stats := make(map[string][]ChannelStat, len(m.chs))
+	for i, ch := range m.fieldChs {
+		name := m.fieldNames[i]
+		stats[name] = append(stats[name], ChannelStat{Len: int(m.fields[i].Integer), Cap: ch.Cap()})
+	}
+	return stats
+}
+
+// tick 执行一次定时采样
+func (m *MonitorChs[T]) tick() {
+	m.mu.Lock()
+	events := m.sample()
+	m.mu.Unlock()
+
+	m.fire(events)
+}
+
+// fastSample 记录各通道自上次输出以来的最大长度
+func (m *MonitorChs[T]) fastSample() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, ch := range m.fieldChs {
+		if l := ch.Len(); l > m.maxLens[i] {
+			m.maxLens[i] = l
+		}
+	}
+}
+
+// fire 触发采样中收集的阈值和告警状态回调, 调用方不能持有m.mu, 回调中可以调用Snapshot、SampleNow等方法
+func (m *MonitorChs[T]) fire(events sampleEvents) {
+	for _, hit := range events.hits {
+		m.onThreshold(hit.name, hit.index, hit.length, hit.capacity)
+	}
+	for _, change := range events.states {
+		m.onStateChange(change.name, change.index, change.state)
+	}
+}
+
+// sample 采样各通道长度并输出日志, 返回需要触发的回调, 调用方需持有m.mu
+func (m *MonitorChs[T]) sample() sampleEvents {
+	if len(m.fieldChs) == 0 {
+		return sampleEvents{}
+	}
+	// 仅更新长度值, 键名在注册时已生成
+	m.changed = m.changed[:0]
+	ll := len(m.fieldChs)
+	var hits []thresholdHit
+	var breach bool
+	for i, ch := range m.fieldChs {
+		l := ch.Len()
+		m.fields[i] = zap.Int(m.fieldKeys[i], l)
+		if m.lenGauges != nil {
+			m.lenGauges[i].Set(float64(l))
+		}
+		if c := ch.Cap(); c > 0 {
+			ratio := float64(l) / float64(c)
+			if m.onThreshold != nil && ratio > m.threshold {
+				hits = append(hits, thresholdHit{name: m.fieldNames[i], index: m.fieldIdx[i], length: l, capacity: c})
+				breach = true
+			}
+			if m.alertRatio > 0 && ratio > m.alertRatio {
+				breach = true
+			}
+		}
+		changed := l != m.lastLens[i]
+		if changed {
+			m.changed = append(m.changed, m.fields[i])
+		}
+		if m.maxLens != nil {
+			m.fields[ll+2*i] = zap.Int(m.maxKeys[i], max(m.maxLens[i], l))
+			m.fields[ll+2*i+1] = zap.Int(m.deltaKeys[i], l-m.lastLens[i])
+			m.maxLens[i] = 0
+			if changed {
+				m.changed = append(m.changed, m.fields[ll+2*i], m.fields[ll+2*i+1])
+			}
+		}
+		m.lastLens[i] = l
+	}
+
+	events := sampleEvents{hits: hits, states: m.checkStates()}
+	m.writeSinks()
+
+	fields := m.fields
+	if m.onlyOnChange {
+		if len(m.changed) == 0 {
+			return events
+		}
+		fields = m.changed
+	}
+
+	// 确保hLog不为nil, 超过阈值时升级为Warn
+	switch {
+	case m.hLog == nil:
+	case breach:
+		m.hLog.Warn("ch len monitor", fields...)
+	default:
+		m.report("ch len monitor", fields...)
+	}
+	return events
+}
+
+// writeSinks 将采样结果写入额外输出, 调用方需持有m.mu
+func (m *MonitorChs[T]) writeSinks() {
+	if len(m.sinks) == 0 {
+		return
+	}
+	now := time.Now()
+	stats := m.stats()
+	for _, sink := range m.sinks {
+		if err := sink.WriteSample(now, stats); err != nil && m.hLog != nil {
+			m.hLog.Error("ch monitor sink write failed", zap.Error(err))
+		}
+	}
+}
+
+// checkStates 检查各通道告警状态, 返回状态发生变化的通道, 调用方需持有m.mu
+func (m *MonitorChs[T]) checkStates() []stateChange {
+	if m.alertRatio <= 0 || m.onStateChange == nil {
+		return nil
+	}
+	if m.alertStates == nil {
+		m.alertStates = make(map[string][]bool)
+	}
+	var changes []stateChange
+	for name, chs := range m.chs {
+		states := m.alertStates[name]
+		if len(states) != len(chs) {
+			states = make([]bool, len(chs))
+			m.alertStates[name] = states
+		}
+		for i, ch := range chs {
+			c := ch.Cap()
+			if c == 0 {
+				continue
+			}
+			alert := float64(ch.Len())/float64(c) > m.alertRatio
+			if alert == states[i] {
+				continue
+			}
+			states[i] = alert
+			state := StateOK
+			if alert {
+				state = StateAlert
+			}
+			changes = append(changes, stateChange{name: name, index: i, state: state})
+		}
+	}
+	return changes
+}
+
 func (m *MonitorChs[T]) Stop() {
-	var once sync.Once
-	once.Do(func() {
-		if m.quitCh != nil {
+	_ = m.StopContext(context.Background())
+}
+
+// StopContext 停止监控, 等待监控协程退出(最多到ctx结束)后刷新并关闭所有额外输出, 返回聚合后的错误
+//
+// 可重复调用, 未调用Run时只关闭额外输出
+func (m *MonitorChs[T]) StopContext(ctx context.Context) error {
+	// 未Run时不消耗stopOnce, 之后Run的监控协程仍可被停止
+	if m.quitCh != nil {
+		m.stopOnce.Do(func() {
+			m.cancel()
 			m.quitCh <- struct{}{}
 			close(m.quitCh)
+		})
+	}
+
+	var errs []error
+	if m.doneCh != nil {
+		select {
+		case <-m.doneCh:
+		case <-ctx.Done():
+			errs = append(errs, ctx.Err())
 		}
-	})
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, sink := range m.sinks {
+		if err := sink.Flush(); err != nil {
+			errs = append(errs, err)
+		}
+		if err := sink.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	m.sinks = nil
+	m.unregisterMetrics()
+	return errors.Join(errs...)
 }
@@ -9,12 +9,50 @@
 package monitorchs
 
 import (
+	"context"
+	"encoding/csv"
+	"fmt"
 	"github.com/calmu/hgotool/hlog"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"os"
+	"path/filepath"
+	"reflect"
 	"sync"
 	"testing"
 	"time"
 )
 
+// recordLogger 记录最近一次输出字段的HLoggerBase
+type recordLogger struct {
+	msgs   []string
+	fields []zap.Field
+}
+
+func (l *recordLogger) Warn(msg string, fields ...zap.Field) {
+	l.msgs = append(l.msgs, msg)
+	l.fields = append(l.fields[:0], fields...)
+}
+
+func (l *recordLogger) Error(msg string, fields ...zap.Field) {
+	l.Warn(msg, fields...)
+}
+
+// fieldMap 将记录的字段转换为键值map
+func (l *recordLogger) fieldMap() map[string]interface{} {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range l.fields {
+		f.AddTo(enc)
+	}
+	return enc.Fields
+}
+
+// nopLogger 丢弃所有输出的HLoggerBase
+type nopLogger struct{}
+
+func (nopLogger) Warn(string, ...zap.Field)  {}
+func (nopLogger) Error(string, ...zap.Field) {}
+
 func TestMonitorChs(t *testing.T) {
 	chs := make([]chan string, 0, 10)
 	for i := 0; i < 10; i++ {
@@ -141,3 +179,571 @@ func TestMonitorChsMultipleTypes(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestMonitorChsOnStateChange(t *testing.T) {
+	ch := make(chan int, 10)
+
+	var states []string
+	m := NewMonitorChs(
+		WithCh("state", ch),
+		WithAlertRatio[int](0.5),
+		WithOnStateChange[int](func(name string, idx int, state string) {
+			if name != "state" || idx != 0 {
+				t.Errorf("unexpected channel %s[%d]", name, idx)
+			}
+			states = append(states, state)
+		}),
+	)
+
+	// 低于阈值, 不触发
+	m.tick()
+
+	// 超过阈值, 连续多次采样只触发一次
+	for i := 0; i < 8; i++ {
+		ch <- i
+	}
+	m.tick()
+	m.tick()
+
+	// 回落到阈值以下
+	for i := 0; i < 6; i++ {
+		<-ch
+	}
+	m.tick()
+	m.tick()
+
+	if len(states) != 2 || states[0] != StateAlert || states[1] != StateOK {
+		t.Errorf("expected [alert ok], got %v", states)
+	}
+}
+
+// TestMonitorChsStateChangeReentrant 测试状态变化回调中调用监控方法不会死锁
+func TestMonitorChsStateChangeReentrant(t *testing.T) {
+	ch := make(chan int, 4)
+	var m *MonitorChs[int]
+	var lens []int
+	m = NewMonitorChs(
+		WithCh("state", ch),
+		WithAlertRatio[int](0.5),
+		WithOnStateChange[int](func(name string, idx int, state string) {
+			lens = append(lens, m.Snapshot()[name][idx])
+			m.SampleNow()
+		}),
+	)
+	for i := 0; i < 3; i++ {
+		ch <- i
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.tick()
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("tick deadlocked in state change callback")
+	}
+	if len(lens) != 1 || lens[0] != 3 {
+		t.Errorf("expected one callback seeing length 3, got %v", lens)
+	}
+}
+
+func TestMonitorChsTickFields(t *testing.T) {
+	a := []chan int{make(chan int, 10), make(chan int, 10)}
+	b := make(chan int, 10)
+	rec := &recordLogger{}
+	m := NewMonitorChs(WithChs("a", a), WithCh("b", b), WithLog[int](rec))
+
+	a[1] <- 1
+	a[1] <- 2
+	b <- 3
+	m.tick()
+	<-a[1]
+	m.tick()
+
+	got := rec.fieldMap()
+	want := map[string]interface{}{"ach0 len": int64(0), "ach1 len": int64(1), "bch0 len": int64(1)}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d fields, got %v", len(want), got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("field %q: expected %v, got %v", k, v, got[k])
+		}
+	}
+}
+
+// maxTickAllocs 每次采样允许的最大分配次数, 不应随通道数增长
+const maxTickAllocs = 2
+
+// TestMonitorChsTickAllocs 测试采样复用字段名和字段切片, 分配次数不随通道数增长
+func TestMonitorChsTickAllocs(t *testing.T) {
+	chs := make([]chan int, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		chs = append(chs, make(chan int, 10))
+	}
+	m := NewMonitorChs(WithChs("bench", chs), WithLog[int](nopLogger{}))
+
+	if allocs := testing.AllocsPerRun(100, m.tick); allocs > maxTickAllocs {
+		t.Errorf("expected at most %d allocations per tick with 1000 channels, got %v", maxTickAllocs, allocs)
+	}
+}
+
+func BenchmarkMonitorChsTick(b *testing.B) {
+	chs := make([]chan int, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		chs = append(chs, make(chan int, 10))
+	}
+	m := NewMonitorChs(WithChs("bench", chs), WithLog[int](nopLogger{}))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.tick()
+	}
+}
+
+func TestMonitorChsSampleNow(t *testing.T) {
+	chs := []chan string{make(chan string, 10), make(chan string, 5)}
+	chs[0] <- "a"
+	chs[0] <- "b"
+	chs[1] <- "c"
+
+	rec := &recordLogger{}
+	m := NewMonitorChs(WithChs("test", chs), WithLog[string](rec))
+
+	stats := m.SampleNow()
+	want := []ChannelStat{{Len: 2, Cap: 10}, {Len: 1, Cap: 5}}
+	if len(stats) != 1 || len(stats["test"]) != len(want) {
+		t.Fatalf("unexpected stats: %v", stats)
+	}
+	for i, w := range want {
+		if stats["test"][i] != w {
+			t.Errorf("channel %d: expected %+v, got %+v", i, w, stats["test"][i])
+		}
+	}
+	if len(rec.msgs) != 1 || rec.msgs[0] != "ch len monitor" {
+		t.Errorf("expected one monitor log entry, got %v", rec.msgs)
+	}
+}
+
+func TestMonitorChsOnlyOnChange(t *testing.T) {
+	static := make(chan int, 10)
+	changing := make(chan int, 10)
+	rec := &recordLogger{}
+	m := NewMonitorChs(WithCh("static", static), WithCh("changing", changing), WithLog[int](rec), WithOnlyOnChange[int](true))
+
+	// 没有变化时不输出
+	m.tick()
+	if len(rec.msgs) != 0 {
+		t.Fatalf("expected no output without changes, got %v", rec.msgs)
+	}
+
+	for i := 1; i <= 3; i++ {
+		changing <- i
+		m.tick()
+		if len(rec.msgs) != i {
+			t.Fatalf("expected %d outputs, got %d", i, len(rec.msgs))
+		}
+		got := rec.fieldMap()
+		if len(got) != 1 || got["changingch0 len"] != int64(i) {
+			t.Errorf("expected only changing channel with len %d, got %v", i, got)
+		}
+	}
+
+	// 再次无变化
+	m.tick()
+	if len(rec.msgs) != 3 {
+		t.Errorf("expected no output for unchanged tick, got %d outputs", len(rec.msgs))
+	}
+}
+
+func TestMonitorChsStopFlushesCSVSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "monitor.csv")
+	sink, err := NewCSVSink(path)
+	if err != nil {
+		t.Fatalf("NewCSVSink failed: %v", err)
+	}
+
+	chs := []chan int{make(chan int, 10), make(chan int, 10)}
+	m := NewMonitorChs(WithChs("csv", chs), WithLog[int](nopLogger{}), WithSink[int](sink))
+
+	m.SampleNow()
+	chs[1] <- 1
+	m.SampleNow()
+
+	if err := m.StopContext(context.Background()); err != nil {
+		t.Fatalf("StopContext failed: %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open csv failed: %v", err)
+	}
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv failed: %v", err)
+	}
+	// 表头 + 2次采样 * 2个通道
+	if len(records) != 5 {
+		t.Fatalf("expected 5 rows, got %d: %v", len(records), records)
+	}
+	last := records[4]
+	if last[1] != "csv" || last[2] != "1" || last[3] != "1" || last[4] != "10" {
+		t.Errorf("unexpected last row: %v", last)
+	}
+}
+
+func TestMonitorChsThreshold(t *testing.T) {
+	chs := []chan int{make(chan int, 10), make(chan int, 10)}
+
+	type hit struct {
+		name                    string
+		index, length, capacity int
+	}
+	var hits []hit
+	var m *MonitorChs[int]
+	m = NewMonitorChs(
+		WithChs("pool", chs),
+		WithLog[int](nopLogger{}),
+		WithThreshold[int](0.5, func(name string, index int, length, capacity int) {
+			// 回调在锁外执行, 获取采样锁不会死锁
+			m.mu.Lock()
+			m.mu.Unlock()
+			hits = append(hits, hit{name, index, length, capacity})
+		}),
+	)
+
+	// 低于阈值, 不触发
+	for i := 0; i < 5; i++ {
+		chs[1] <- i
+	}
+	m.tick()
+	if len(hits) != 0 {
+		t.Fatalf("expected no hits, got %v", hits)
+	}
+
+	// 超过阈值期间每次采样都触发
+	chs[1] <- 5
+	m.tick()
+	m.tick()
+	want := hit{"pool", 1, 6, 10}
+	if len(hits) != 2 || hits[0] != want || hits[1] != want {
+		t.Errorf("expected repeated %v, got %v", want, hits)
+	}
+}
+
+// levelLogger 记录每次输出使用的级别
+type levelLogger struct {
+	levels []string
+}
+
+func (l *levelLogger) Debug(string, ...zap.Field) { l.levels = append(l.levels, "debug") }
+func (l *levelLogger) Info(string, ...zap.Field)  { l.levels = append(l.levels, "info") }
+func (l *levelLogger) Warn(string, ...zap.Field)  { l.levels = append(l.levels, "warn") }
+func (l *levelLogger) Error(string, ...zap.Field) { l.levels = append(l.levels, "error") }
+
+func TestMonitorChsLevel(t *testing.T) {
+	chs := []chan int{make(chan int, 10)}
+	l := &levelLogger{}
+	m := NewMonitorChs(
+		WithChs("pool", chs),
+		WithLog[int](l),
+		WithLevel[int]("info"),
+		WithAlertRatio[int](0.5),
+	)
+
+	// 常规输出使用Info
+	m.SampleNow()
+	// 超过阈值时升级为Warn
+	for i := 0; i < 6; i++ {
+		chs[0] <- i
+	}
+	m.SampleNow()
+	// 回落后恢复Info
+	<-chs[0]
+	<-chs[0]
+	m.SampleNow()
+
+	want := []string{"info", "warn", "info"}
+	if !reflect.DeepEqual(l.levels, want) {
+		t.Errorf("expected levels %v, got %v", want, l.levels)
+	}
+
+	// 无效级别回退到Warn并报告
+	_, err := NewMonitorChsE(WithChs("pool", chs), WithLog[int](l), WithLevel[int]("trace"))
+	if err == nil {
+		t.Error("expected error for invalid level")
+	}
+}
+
+func TestMonitorChsAddRemoveWhileRunning(t *testing.T) {
+	m := NewMonitorChs(
+		WithCh("base", make(chan int, 10)),
+		WithDuration[int](time.Millisecond),
+		WithLog[int](nopLogger{}),
+		WithAlertRatio[int](0.5),
+		WithOnStateChange[int](func(string, int, string) {}),
+	)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	m.Run(&wg)
+
+	// 监控运行期间增删通道, 需配合 go test -race 检查
+	var mutators sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		mutators.Add(1)
+		go func(g int) {
+			defer mutators.Done()
+			name := fmt.Sprintf("pool%d", g)
+			for i := 0; i < 50; i++ {
+				ch := make(chan int, 4)
+				ch <- i
+				m.AddChannels(name, ch)
+				if i%10 == 9 {
+					m.RemoveGroup(name)
+				}
+				time.Sleep(100 * time.Microsecond)
+			}
+		}(g)
+	}
+	mutators.Wait()
+
+	m.AddChannels("extra", make(chan int, 2), make(chan int, 2))
+	m.RemoveGroup("missing")
+	stats := m.SampleNow()
+
+	m.Stop()
+	wg.Wait()
+
+	if len(stats["base"]) != 1 || len(stats["extra"]) != 2 {
+		t.Errorf("unexpected groups: %v", stats)
+	}
+	for g := 0; g < 4; g++ {
+		if _, ok := stats[fmt.Sprintf("pool%d", g)]; ok {
+			t.Errorf("expected pool%d to be removed, got %v", g, stats)
+		}
+	}
+}
+
+func TestMonitorChsStopIdempotent(t *testing.T) {
+	// 未Run时Stop不panic
+	m := NewMonitorChs(WithCh("idle", make(chan int, 1)), WithLog[int](nopLogger{}))
+	m.Stop()
+	m.Stop()
+
+	// Run后重复Stop不panic
+	m = NewMonitorChs(WithCh("run", make(chan int, 1)), WithDuration[int](time.Millisecond), WithLog[int](nopLogger{}))
+	var wg sync.WaitGroup
+	wg.Add(1)
+	m.Run(&wg)
+	m.Stop()
+	m.Stop()
+	wg.Wait()
+
+	// 先Stop后Run, 之后的Stop仍能停止监控协程
+	m = NewMonitorChs(WithCh("late", make(chan int, 1)), WithDuration[int](time.Millisecond), WithLog[int](nopLogger{}))
+	m.Stop()
+	wg.Add(1)
+	m.Run(&wg)
+	m.Stop()
+	wg.Wait()
+}
+
+func TestMonitorChsRunContext(t *testing.T) {
+	m := NewMonitorChs(WithCh("ctx", make(chan int, 1)), WithDuration[int](time.Millisecond), WithLog[int](nopLogger{}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	m.RunContext(ctx, &wg)
+
+	time.Sleep(5 * time.Millisecond)
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("monitor goroutine did not exit after context cancel")
+	}
+
+	// ctx取消后Stop仍可安全调用
+	if err := m.StopContext(context.Background()); err != nil {
+		t.Errorf("unexpected stop error: %v", err)
+	}
+}
+
+func TestMonitorChsSnapshot(t *testing.T) {
+	chs := []chan int{make(chan int, 4), make(chan int, 4)}
+	rec := &recordLogger{}
+	m := NewMonitorChs(WithChs("snap", chs), WithLog[int](rec))
+
+	chs[0] <- 1
+	chs[1] <- 1
+	chs[1] <- 2
+	m.AddChannels("extra", make(chan int, 1))
+
+	snapshot := m.Snapshot()
+	if len(snapshot) != 2 || len(snapshot["snap"]) != 2 || snapshot["snap"][0] != 1 || snapshot["snap"][1] != 2 {
+		t.Errorf("unexpected snapshot: %v", snapshot)
+	}
+	if len(snapshot["extra"]) != 1 || snapshot["extra"][0] != 0 {
+		t.Errorf("unexpected extra group: %v", snapshot)
+	}
+	if len(rec.msgs) != 0 {
+		t.Errorf("expected no log output, got %v", rec.msgs)
+	}
+}
+
+func TestMonitorChsSampleInterval(t *testing.T) {
+	ch := make(chan int, 10)
+	rec := &recordLogger{}
+	m := NewMonitorChs(WithCh("burst", ch), WithLog[int](rec), WithSampleInterval[int](time.Millisecond))
+
+	ch <- 1
+	m.tick()
+	fields := rec.fieldMap()
+	if fields["burstch0 max"] != int64(1) || fields["burstch0 delta"] != int64(1) {
+		t.Errorf("unexpected first report: %v", fields)
+	}
+
+	// 两次输出之间写满后又被消费
+	for i := 0; i < 7; i++ {
+		ch <- i
+	}
+	m.fastSample()
+	for i := 0; i < 8; i++ {
+		<-ch
+	}
+	m.fastSample()
+	m.tick()
+	fields = rec.fieldMap()
+	if fields["burstch0 len"] != int64(0) || fields["burstch0 max"] != int64(8) || fields["burstch0 delta"] != int64(-1) {
+		t.Errorf("expected burst max 8, got %v", fields)
+	}
+
+	// 输出后计数重置
+	m.tick()
+	fields = rec.fieldMap()
+	if fields["burstch0 max"] != int64(0) || fields["burstch0 delta"] != int64(0) {
+		t.Errorf("expected counters reset, got %v", fields)
+	}
+}
+
+func TestMonitorChsSampleIntervalRun(t *testing.T) {
+	ch := make(chan int, 10)
+	m := NewMonitorChs(
+		WithCh("burst", ch),
+		WithLog[int](nopLogger{}),
+		WithDuration[int](time.Hour),
+		WithSampleInterval[int](time.Millisecond),
+	)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	m.Run(&wg)
+	for i := 0; i < 5; i++ {
+		ch <- i
+	}
+	time.Sleep(20 * time.Millisecond)
+	for i := 0; i < 5; i++ {
+		<-ch
+	}
+
+	m.mu.Lock()
+	got := m.maxLens[0]
+	m.mu.Unlock()
+	m.Stop()
+	wg.Wait()
+
+	if got != 5 {
+		t.Errorf("expected fast ticker to capture max 5, got %d", got)
+	}
+}
+
+func TestMonitorChsInvalidChannels(t *testing.T) {
+	good := make(chan int, 4)
+	unbuffered := make(chan int)
+	var nilCh chan int
+
+	// 默认构造跳过nil通道并输出警告
+	rec := &recordLogger{}
+	m := NewMonitorChs(WithChs("mixed", []chan int{good, nilCh}), WithCh("more", nilCh), WithLog[int](rec))
+	if stats := m.Snapshot(); len(stats["mixed"]) != 1 || len(stats["more"]) != 0 {
+		t.Errorf("expected nil channels to be excluded, got %v", stats)
+	}
+	if len(rec.msgs) != 1 || rec.msgs[0] != "ch monitor invalid channels" {
+		t.Errorf("expected one warning, got %v", rec.msgs)
+	}
+
+	// NewMonitorChsE对nil和无缓冲通道返回错误
+	if _, err := NewMonitorChsE(WithChs("mixed", []chan int{good, nilCh}), WithLog[int](nopLogger{})); err == nil {
+		t.Error("expected error for nil channel")
+	}
+	if _, err := NewMonitorChsE(WithCh("sync", unbuffered), WithLog[int](nopLogger{})); err == nil {
+		t.Error("expected error for unbuffered channel")
+	}
+	m, err := NewMonitorChsE(WithCh("ok", good), WithLog[int](nopLogger{}))
+	if err != nil || m == nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 运行期间追加时同样跳过nil通道
+	m.AddChannels("ok", nilCh)
+	m.AddChannels("empty", nilCh)
+	if stats := m.Snapshot(); len(stats) != 1 || len(stats["ok"]) != 1 {
+		t.Errorf("expected nil channels to be skipped, got %v", stats)
+	}
+}
+
+func TestMonitorAny(t *testing.T) {
+	stringCh := make(chan string, 10)
+	intCh := make(chan int, 5)
+	stringCh <- "a"
+	stringCh <- "b"
+	intCh <- 1
+
+	rec := &recordLogger{}
+	m := NewMonitorAny(
+		WithAnyChs[any]("mixed", stringCh, intCh),
+		WithLog[any](rec),
+	)
+
+	stats := m.SampleNow()
+	want := []ChannelStat{{Len: 2, Cap: 10}, {Len: 1, Cap: 5}}
+	if len(stats["mixed"]) != len(want) || stats["mixed"][0] != want[0] || stats["mixed"][1] != want[1] {
+		t.Errorf("unexpected stats: %v", stats)
+	}
+	fields := rec.fieldMap()
+	if fields["mixedch0 len"] != int64(2) || fields["mixedch1 len"] != int64(1) {
+		t.Errorf("unexpected fields: %v", fields)
+	}
+
+	// 运行期间追加其他类型的通道, 非通道值和nil通道被跳过
+	var nilCh chan float64
+	floatCh := make(chan float64, 3)
+	floatCh <- 1.5
+	if err := m.AddAnyChannels("other", floatCh, "not a channel", nilCh); err == nil {
+		t.Error("expected error for invalid inputs")
+	}
+	if snapshot := m.Snapshot(); len(snapshot["other"]) != 1 || snapshot["other"][0] != 1 {
+		t.Errorf("unexpected snapshot: %v", snapshot)
+	}
+
+	// 类型化监控器同样可以追加其他类型的通道
+	typed := NewMonitorChs(WithCh("typed", make(chan int, 1)), WithLog[int](nopLogger{}))
+	if err := typed.AddAnyChannels("typed", stringCh); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if snapshot := typed.Snapshot(); len(snapshot["typed"]) != 2 || snapshot["typed"][1] != 2 {
+		t.Errorf("unexpected typed snapshot: %v", snapshot)
+	}
+}
@@ -0,0 +1,80 @@
+package monitorchs
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// chanProbe 读取被监控通道的长度和容量, 屏蔽通道的元素类型
+type chanProbe interface {
+	Len() int
+	Cap() int
+}
+
+// typedProbe 类型化通道, 用于WithChs/WithCh/AddChannels注册的chan T
+type typedProbe[T any] chan T
+
+func (c typedProbe[T]) Len() int { return len(c) }
+func (c typedProbe[T]) Cap() int { return cap(c) }
+
+// reflectProbe 通过反射读取任意类型的通道, 用于WithAnyChs/AddAnyChannels
+type reflectProbe struct {
+	v reflect.Value
+}
+
+func (p reflectProbe) Len() int { return p.v.Len() }
+func (p reflectProbe) Cap() int { return p.v.Cap() }
+
+// MonitorAny 可同时监控不同元素类型通道的监控器, 通过WithAnyChs或AddAnyChannels注册通道
+type MonitorAny = MonitorChs[any]
+
+// NewMonitorAny 创建MonitorAny, 其他选项与NewMonitorChs相同(类型参数为any)
+func NewMonitorAny(options ...Options[any]) *MonitorAny {
+	return NewMonitorChs(options...)
+}
+
+// validAnyChs 通过反射校验任意类型的通道, 非通道值和nil通道会被跳过, 无缓冲通道保留但返回错误
+func validAnyChs(name string, chs []interface{}) ([]chanProbe, []error) {
+	var errs []error
+	valid := make([]chanProbe, 0, len(chs))
+	for i, ch := range chs {
+		v := reflect.ValueOf(ch)
+		switch {
+		case v.Kind() != reflect.Chan:
+			errs = append(errs, fmt.Errorf("channel %s[%d] is %T, not a channel, and will be skipped", name, i, ch))
+			continue
+		case v.IsNil():
+			errs = append(errs, fmt.Errorf("channel %s[%d] is nil and will be skipped", name, i))
+			continue
+		case v.Cap() == 0:
+			errs = append(errs, fmt.Errorf("channel %s[%d] is unbuffered, its length is always 0", name, i))
+		}
+		valid = append(valid, reflectProbe{v: v})
+	}
+	return valid, errs
+}
+
+// WithAnyChs 向name组追加任意元素类型的通道(如chan string和chan int混用), 非通道值和nil通道会被跳过
+func WithAnyChs[T any](name string, chs ...interface{}) Options[T] {
+	return func(m *MonitorChs[T]) {
+		if m.chs == nil {
+			m.chs = make(map[string][]chanProbe)
+		}
+		valid, errs := validAnyChs(name, chs)
+		m.chs[name] = append(m.chs[name], valid...)
+		m.invalid = append(m.invalid, errs...)
+	}
+}
+
+// AddAnyChannels 在运行期间向name组追加任意元素类型的通道, 非通道值和nil通道会被跳过并输出警告日志
+//
+// 返回所有无效输入的汇总错误, 包括仍被监控的无缓冲通道
+func (m *MonitorChs[T]) AddAnyChannels(name string, chs ...interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	valid, errs := validAnyChs(name, chs)
+	m.addProbes(name, valid, errs)
+	return errors.Join(errs...)
+}
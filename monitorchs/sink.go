@@ -0,0 +1,92 @@
+package monitorchs
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Sink 采样结果的额外输出, 每次采样后调用WriteSample, Stop时依次调用Flush和Close
+type Sink interface {
+	WriteSample(ts time.Time, stats map[string][]ChannelStat) error
+	Flush() error
+	Close() error
+}
+
+// CSVSink 将采样结果写入CSV文件, 每个通道一行: time,name,index,len,cap
+//
+// 写入带缓冲, 需调用Flush或Close才能保证数据落盘
+type CSVSink struct {
+	mu     sync.Mutex
+	file   *os.File
+	writer *csv.Writer
+}
+
+// NewCSVSink 创建CSV输出, 文件不存在或为空时写入表头
+func NewCSVSink(path string) (*CSVSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &CSVSink{file: file, writer: csv.NewWriter(file)}
+	if stat, err := file.Stat(); err == nil && stat.Size() == 0 {
+		if err := s.writer.Write([]string{"time", "name", "index", "len", "cap"}); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// WriteSample 实现Sink接口, 按通道组名称排序输出
+func (s *CSVSink) WriteSample(ts time.Time, stats map[string][]ChannelStat) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make([]string, 0, len(stats))
+	for name := range stats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	t := ts.Format(time.RFC3339Nano)
+	for _, name := range names {
+		for i, stat := range stats[name] {
+			record := []string{t, name, strconv.Itoa(i), strconv.Itoa(stat.Len), strconv.Itoa(stat.Cap)}
+			if err := s.writer.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Flush 实现Sink接口
+func (s *CSVSink) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.writer.Flush()
+	return s.writer.Error()
+}
+
+// Close 实现Sink接口
+func (s *CSVSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.writer.Flush()
+	err := s.writer.Error()
+	if cerr := s.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}